@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"text/tabwriter"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/handler"
+)
+
+// cmdCompare compares the stored results of two test runs.
+const cmdCompare = "compare"
+
+// compare compares the stored results of the base and candidate test run, prints the comparison
+// and returns the exit code (0: no regression, 1: at least one regression or error).
+func compare() int {
+	testHandler, err := handler.NewTestHandler(log.Printf)
+	checkErr(err)
+
+	c := testHandler.Compare(env.Base(), env.Candidate(), env.Threshold())
+	if c.Error != "" {
+		log.Print(c.Error)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Test\tBatchCount\tBatchSize\tWorkers\tBufferSize\tBulkSize\t%s sec/op\t%s sec/op\tDelta\tP\tN\t\n", c.Base, c.Candidate)
+	for _, tc := range c.Tests {
+		delta := "~"
+		if tc.Significant {
+			delta = fmt.Sprintf("%+.2f%%", tc.Delta)
+		}
+		regression := ""
+		if tc.Regression {
+			regression = "regression"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%.4f\t%.4f\t%s\t%.3f\t%d+%d\t%s\n", path.Base(tc.Test), tc.BatchCount, tc.BatchSize, tc.Workers, tc.BufferSize, tc.BulkSize, tc.BaseMedian, tc.CandidateMedian, delta, tc.P, len(tc.BaseSamples), len(tc.CandidateSamples), regression)
+	}
+	w.Flush()
+
+	log.Printf("%s", c)
+	if c.Regressions != 0 {
+		return 1
+	}
+	return 0
+}
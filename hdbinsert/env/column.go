@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Column represents a test table column definition consisting of
+// column name, HANA data type and an optional value generator.
+type Column struct {
+	Name, Type, Generator string
+}
+
+func (c Column) String() string {
+	if c.Generator == "" {
+		return c.Name + " " + c.Type
+	}
+	return c.Name + " " + c.Type + " " + c.Generator
+}
+
+// parseColumn parses a column definition of format
+//
+//	<name> <type> [<generator>]
+//
+// like
+//
+//	TEMPERATUR DOUBLE uniform(25, 26)
+func parseColumn(s string) (Column, error) {
+	fields, err := columnFields(s)
+	if err != nil {
+		return Column{}, fmt.Errorf("invalid column definition: %s", s)
+	}
+	if len(fields) < 2 || len(fields) > 3 {
+		return Column{}, fmt.Errorf("invalid column definition: %s", s)
+	}
+	c := Column{Name: fields[0], Type: strings.ToUpper(fields[1])}
+	if len(fields) == 3 {
+		c.Generator = fields[2]
+	}
+	return c, nil
+}
+
+// columnFields splits s around white space outside of parentheses,
+// so that types and generators like DECIMAL(18, 2) or uniform(25, 26) are kept as one field.
+func columnFields(s string) ([]string, error) {
+	var fields []string
+	depth, start := 0, -1
+	for i, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case unicode.IsSpace(r) && depth == 0:
+			if start != -1 {
+				fields = append(fields, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start == -1 {
+			start = i
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	if start != -1 {
+		fields = append(fields, s[start:])
+	}
+	return fields, nil
+}
+
+// ColumnValue represents a flag Value for test table column definitions.
+// Column definitions are separated by semicolons.
+type ColumnValue struct {
+	Columns []Column
+}
+
+// String implements the flag.Value interface.
+func (v *ColumnValue) String() string {
+	s := make([]string, len(v.Columns))
+	for i, c := range v.Columns {
+		s[i] = c.String()
+	}
+	return strings.Join(s, "; ")
+}
+
+// Set implements the flag.Value interface. In case of an error the columns are left unchanged.
+func (v *ColumnValue) Set(s string) error {
+	columns := []Column{}
+	for _, cs := range strings.Split(s, ";") {
+		if strings.TrimSpace(cs) == "" {
+			continue
+		}
+		c, err := parseColumn(cs)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("invalid value: %s - no columns defined", s)
+	}
+	v.Columns = columns
+	return nil
+}
+
+// readColumnFile reads column definitions from a file with one column definition per line.
+// Empty lines and lines starting with # are ignored.
+func readColumnFile(fn string) ([]Column, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	columns := []Column{}
+	sc := bufio.NewScanner(f)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c, err := parseColumn(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %s", fn, lineNo, err)
+		}
+		columns = append(columns, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s: no columns defined", fn)
+	}
+	return columns, nil
+}
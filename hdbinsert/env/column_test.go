@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColumn(t *testing.T) {
+	for _, test := range []struct {
+		s      string
+		column Column
+		err    bool
+	}{
+		{"ID INTEGER seq", Column{Name: "ID", Type: "INTEGER", Generator: "seq"}, false},
+		{" AMOUNT decimal(18, 2) ", Column{Name: "AMOUNT", Type: "DECIMAL(18, 2)"}, false},
+		{"TEMPERATUR DOUBLE uniform(25, 26)", Column{Name: "TEMPERATUR", Type: "DOUBLE", Generator: "uniform(25, 26)"}, false},
+		{"VALUE DOUBLE null(0.1, uniform(0, 1))", Column{Name: "VALUE", Type: "DOUBLE", Generator: "null(0.1, uniform(0, 1))"}, false},
+		{"ID", Column{}, true},
+		{"ID INTEGER seq extra", Column{}, true},
+		{"AMOUNT DECIMAL(18, 2", Column{}, true},
+		{"AMOUNT DECIMAL)18, 2(", Column{}, true},
+	} {
+		c, err := parseColumn(test.s)
+		if (err != nil) != test.err {
+			t.Fatalf("%s: error %v", test.s, err)
+		}
+		if c != test.column {
+			t.Fatalf("%s: column %v - expected %v", test.s, c, test.column)
+		}
+	}
+}
+
+func TestColumnValue(t *testing.T) {
+	def := []Column{{Name: "ID", Type: "INTEGER", Generator: "seq"}, {Name: "VALUE", Type: "DOUBLE"}}
+	v := &ColumnValue{Columns: append([]Column{}, def...)}
+
+	// keep columns in case of error
+	for _, s := range []string{"A INTEGER; B", " ; "} {
+		if err := v.Set(s); err == nil {
+			t.Fatalf("%s: error expected", s)
+		}
+		if !reflect.DeepEqual(v.Columns, def) {
+			t.Fatalf("%s: columns %v - expected %v", s, v.Columns, def)
+		}
+	}
+
+	if err := v.Set("A INTEGER seq; B DECIMAL(10, 2)"); err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "A INTEGER seq; B DECIMAL(10, 2)" {
+		t.Fatalf("columns %s - expected A INTEGER seq; B DECIMAL(10, 2)", s)
+	}
+}
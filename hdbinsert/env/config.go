@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// flagEnvs maps the command-line flags to their environment variables.
+var flagEnvs = map[string]string{
+	FnDSN:             envDSN,
+	FnHost:            envHost,
+	FnPort:            envPort,
+	FnSchemaName:      envSchemaName,
+	FnTableName:       envTableName,
+	FnColumns:         envColumns,
+	FnColumnFile:      envColumnFile,
+	FnSeed:            envSeed,
+	FnLobType:         envLobType,
+	FnLobSize:         envLobSize,
+	FnBufferSize:      envBufferSize,
+	FnFetchSize:       envFetchSize,
+	FnParameters:      envParameters,
+	FnLobParameters:   envLobParameters,
+	FnWorkers:         envWorkers,
+	FnSoakDuration:    envSoakDuration,
+	FnSoakRate:        envSoakRate,
+	FnSoakWindow:      envSoakWindow,
+	FnSweepBufferSize: envSweepBufferSize,
+	FnSweepBulkSize:   envSweepBulkSize,
+	FnDrop:            envDrop,
+	FnSeparate:        envSeparate,
+	FnWait:            envWait,
+	FnShutdownTimeout: envShutdownTimeout,
+	FnResultFile:      envResultFile,
+	FnLabel:           envLabel,
+	FnThreshold:       envThreshold,
+	FnBase:            envBase,
+	FnCandidate:       envCandidate,
+	FnTests:           envTests,
+	FnCount:           envCount,
+	FnFormat:          envFormat,
+
+	FnTLSRootCAFile:         envTLSRootCAFile,
+	FnTLSServerName:         envTLSServerName,
+	FnTLSInsecureSkipVerify: envTLSInsecureSkipVerify,
+	FnConnectTimeout:        envConnectTimeout,
+	FnIOTimeout:             envIOTimeout,
+	FnLocale:                envLocale,
+	FnApplicationName:       envApplicationName,
+	FnSessionVariables:      envSessionVariables,
+}
+
+// configSections are the names of the config file objects grouping command-line flags.
+var configSections = map[string]bool{"connector": true, "tls": true, "session": true, "table": true, "matrix": true, "soak": true, "sweep": true, "compare": true, "output": true, "server": true}
+
+// LoadConfig sets the command-line flags defined in the config file (see config command-line flag) which are
+// neither set on the command-line nor by an environment variable, so that the precedence is
+// command-line flag > environment variable > config file > default.
+// LoadConfig needs to be called after the command-line flags are parsed.
+func LoadConfig() error {
+	if configFile == "" {
+		return nil
+	}
+	return loadConfig(flag.CommandLine, configFile)
+}
+
+// loadConfig reads the JSON config file fn and sets the flags of fs accordingly.
+//
+// The config file is a JSON object with the flag names as keys (e.g. "dsn" or "bufferSize"). Keys might be grouped
+// in nested objects named by configSections (e.g. "connector": {"bufferSize": 65536}). Lists (parameters, workers, ...)
+// might be defined as arrays, table columns as array of objects with name, type and generator and session variables as object.
+func loadConfig(fs *flag.FlagSet, fn string) error {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var config map[string]interface{}
+	if err := d.Decode(&config); err != nil {
+		return fmt.Errorf("config file %s: %s", fn, err)
+	}
+
+	c := &configLoader{fs: fs, set: map[string]bool{}, keys: map[string]string{}}
+	fs.Visit(func(f *flag.Flag) { c.set[f.Name] = true })
+	if err := c.load("", config); err != nil {
+		return fmt.Errorf("config file %s: %s", fn, err)
+	}
+	return nil
+}
+
+type configLoader struct {
+	fs   *flag.FlagSet
+	set  map[string]bool   // flags set on the command-line
+	keys map[string]string // flag names of the config file keys already loaded
+}
+
+func (c *configLoader) load(prefix string, config map[string]interface{}) error {
+	names := make([]string, 0, len(config))
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := config[name]
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		f := c.fs.Lookup(name)
+		if f == nil {
+			section, ok := value.(map[string]interface{})
+			if !ok || !configSections[name] {
+				return fmt.Errorf("unknown key %s", key)
+			}
+			if err := c.load(key, section); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == FnConfig {
+			return fmt.Errorf("invalid key %s: config files cannot be nested", key)
+		}
+		if other, ok := c.keys[name]; ok {
+			return fmt.Errorf("duplicate key %s (already defined by %s)", key, other)
+		}
+		c.keys[name] = key
+
+		if c.set[name] {
+			continue // command-line flag
+		}
+		if envKey, ok := flagEnvs[name]; ok {
+			if _, ok := os.LookupEnv(envKey); ok {
+				continue // environment variable
+			}
+		}
+
+		s, err := configValue(name, value)
+		if err != nil {
+			return fmt.Errorf("key %s: %s", key, err)
+		}
+		if err := f.Value.Set(s); err != nil {
+			return fmt.Errorf("key %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// configValue converts a config file value into the string representation of the command-line flag name.
+func configValue(name string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case map[string]interface{}:
+		if name != FnSessionVariables {
+			return "", fmt.Errorf("invalid value %v", value)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		s := make([]string, len(keys))
+		for i, k := range keys {
+			ev, err := configValue(name, v[k])
+			if err != nil {
+				return "", err
+			}
+			s[i] = k + "=" + ev
+		}
+		return strings.Join(s, ","), nil
+	case []interface{}:
+		sep := " "
+		switch name {
+		case FnColumns:
+			sep = "; "
+		case FnSessionVariables:
+			sep = ","
+		}
+		s := make([]string, len(v))
+		for i, e := range v {
+			if c, ok := e.(map[string]interface{}); ok && name == FnColumns {
+				column, err := configColumn(c)
+				if err != nil {
+					return "", err
+				}
+				s[i] = column.String()
+				continue
+			}
+			es, err := configValue(name, e)
+			if err != nil {
+				return "", err
+			}
+			s[i] = es
+		}
+		return strings.Join(s, sep), nil
+	default:
+		return "", fmt.Errorf("invalid value %v", value)
+	}
+}
+
+// configColumn converts a column object with name, type and optional generator into a column definition.
+func configColumn(c map[string]interface{}) (Column, error) {
+	field := func(name string) (string, error) {
+		v, ok := c[name]
+		if !ok {
+			return "", nil
+		}
+		s, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("invalid column %s %v", name, v)
+		}
+		return s, nil
+	}
+	for name := range c {
+		if name != "name" && name != "type" && name != "generator" {
+			return Column{}, fmt.Errorf("unknown column key %s", name)
+		}
+	}
+	var column Column
+	var err error
+	if column.Name, err = field("name"); err != nil {
+		return column, err
+	}
+	if column.Type, err = field("type"); err != nil {
+		return column, err
+	}
+	if column.Generator, err = field("generator"); err != nil {
+		return column, err
+	}
+	if column.Name == "" || column.Type == "" {
+		return column, fmt.Errorf("invalid column %v: name and type required", c)
+	}
+	return column, nil
+}
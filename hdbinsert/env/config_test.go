@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	newFlagSet := func() *flag.FlagSet {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.String(FnDSN, "default", "")
+		fs.String(FnSchemaName, "default", "")
+		fs.String(FnTableName, "default", "")
+		fs.Int(FnBufferSize, 0, "")
+		fs.Bool(FnDrop, true, "")
+		fs.Var(&ColumnValue{}, FnColumns, "")
+		fs.Var(&PrmValue{}, FnParameters, "")
+		fs.Var(&WorkersValue{}, FnWorkers, "")
+		return fs
+	}
+	writeConfig := func(s string) string {
+		fn := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(fn, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+
+	fn := writeConfig(`{
+	"dsn": "file",
+	"schemaName": "file",
+	"tableName": "file",
+	"connector": {"bufferSize": 65536},
+	"drop": false,
+	"columns": [{"name": "ID", "type": "integer", "generator": "seq"}, "VALUE DOUBLE"],
+	"matrix": {"parameters": ["1x1000", "10x100"], "workers": [0, 4]}
+}`)
+
+	oldSchemaName, ok := os.LookupEnv(envSchemaName)
+	os.Setenv(envSchemaName, "env")
+	defer func() {
+		if ok {
+			os.Setenv(envSchemaName, oldSchemaName)
+		} else {
+			os.Unsetenv(envSchemaName)
+		}
+	}()
+	fs := newFlagSet()
+	if err := fs.Parse([]string{"-" + FnDSN, "flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfig(fs, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		FnDSN:        "flag",                         // flag > file
+		FnSchemaName: "default",                      // env > file (env is applied at flag definition)
+		FnTableName:  "file",                         // file > default
+		FnBufferSize: "65536",                        // grouped key
+		FnDrop:       "false",                        // bool
+		FnColumns:    "ID INTEGER seq; VALUE DOUBLE", // column objects and definitions
+		FnParameters: "1x1000 10x100",                // list
+		FnWorkers:    "0 4",                          // list of numbers
+	}
+	for name, value := range expected {
+		if s := fs.Lookup(name).Value.String(); s != value {
+			t.Fatalf("%s %s - expected %s", name, s, value)
+		}
+	}
+
+	// errors name the bad key
+	tests := []struct{ config, key string }{
+		{`{"bufferSiz": 1}`, "bufferSiz"},
+		{`{"conector": {"bufferSize": 1}}`, "conector"},
+		{`{"connector": {"tabel": {"tableName": "a"}}}`, "connector.tabel"},
+		{`{"connector": {"bufferSize": "large"}}`, "connector.bufferSize"},
+		{`{"matrix": {"workers": [-1]}}`, "matrix.workers"},
+		{`{"columns": [{"name": "ID"}]}`, "columns"},
+		{`{"tableName": "a", "table": {"tableName": "b"}}`, "tableName"},
+		{`{"drop": null}`, "drop"},
+	}
+	for _, test := range tests {
+		err := loadConfig(newFlagSet(), writeConfig(test.config))
+		if err == nil || !strings.Contains(err.Error(), "key "+test.key) {
+			t.Fatalf("config %s: error %v - expected error naming key %s", test.config, err, test.key)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SessionVariablesValue represents a flag Value for database session variables.
+// Session variables are defined as key value pairs '<key>=<value>' separated by commas.
+type SessionVariablesValue struct {
+	Vars map[string]string
+}
+
+// String implements the flag.Value interface.
+func (v *SessionVariablesValue) String() string {
+	keys := make([]string, 0, len(v.Vars))
+	for k := range v.Vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = k + "=" + v.Vars[k]
+	}
+	return strings.Join(s, ",")
+}
+
+// Set implements the flag.Value interface.
+func (v *SessionVariablesValue) Set(s string) error {
+	vars := map[string]string{}
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		t := strings.SplitN(kv, "=", 2)
+		k := strings.TrimSpace(t[0])
+		if len(t) != 2 || k == "" {
+			return fmt.Errorf("invalid session variable: %s", kv)
+		}
+		vars[k] = strings.TrimSpace(t[1])
+	}
+	v.Vars = vars
+	return nil
+}
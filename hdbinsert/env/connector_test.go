@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"testing"
+)
+
+func TestSessionVariablesValue(t *testing.T) {
+	v := &SessionVariablesValue{}
+	if err := v.Set("b=2, a = x=1,"); err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "a=x=1,b=2" {
+		t.Fatalf("session variables %s - expected a=x=1,b=2", s)
+	}
+	for _, s := range []string{"a", "=1", "a=1,b"} {
+		if err := v.Set(s); err == nil {
+			t.Fatalf("%s: error expected", s)
+		}
+	}
+}
@@ -39,12 +39,7 @@ func (v *PrmValue) String() string {
 
 // Set implements the flag.Value interface.
 func (v *PrmValue) Set(s string) error {
-	if v.Prms == nil {
-		v.Prms = []Prm{}
-	} else {
-		v.Prms = v.Prms[:0]
-	}
-
+	prms := []Prm{}
 	for _, ts := range strings.Split(s, " ") {
 		t := strings.Split(ts, "x")
 		if len(t) != 2 {
@@ -60,8 +55,9 @@ func (v *PrmValue) Set(s string) error {
 		if err != nil {
 			return err
 		}
-		v.Prms = append(v.Prms, prm)
+		prms = append(prms, prm)
 	}
+	v.Prms = prms
 	return nil
 }
 
@@ -88,3 +84,71 @@ func (v *PrmValue) ToNumRecordList() [][]Prm {
 	}
 	return r
 }
+
+// WorkersValue represents a flag Value for the number of workers of parallel tests.
+type WorkersValue struct {
+	Workers []int
+}
+
+// String implements the flag.Value interface.
+func (v *WorkersValue) String() string {
+	s := make([]string, len(v.Workers))
+	for i, workers := range v.Workers {
+		s[i] = strconv.Itoa(workers)
+	}
+	return strings.Join(s, " ")
+}
+
+// Set implements the flag.Value interface.
+func (v *WorkersValue) Set(s string) error {
+	workers := []int{}
+	for _, ws := range strings.Fields(s) {
+		n, err := strconv.Atoi(ws)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid number of workers: %d", n)
+		}
+		workers = append(workers, n)
+	}
+	if len(workers) == 0 {
+		return fmt.Errorf("invalid value: %s", s)
+	}
+	v.Workers = workers
+	return nil
+}
+
+// SizeValue represents a flag Value for a list of sizes (e.g. buffer sizes).
+type SizeValue struct {
+	Sizes []int
+}
+
+// String implements the flag.Value interface.
+func (v *SizeValue) String() string {
+	s := make([]string, len(v.Sizes))
+	for i, size := range v.Sizes {
+		s[i] = strconv.Itoa(size)
+	}
+	return strings.Join(s, " ")
+}
+
+// Set implements the flag.Value interface.
+func (v *SizeValue) Set(s string) error {
+	sizes := []int{}
+	for _, ss := range strings.Fields(s) {
+		n, err := strconv.Atoi(ss)
+		if err != nil {
+			return err
+		}
+		if n <= 0 {
+			return fmt.Errorf("invalid size: %d", n)
+		}
+		sizes = append(sizes, n)
+	}
+	if len(sizes) == 0 {
+		return fmt.Errorf("invalid value: %s", s)
+	}
+	v.Sizes = sizes
+	return nil
+}
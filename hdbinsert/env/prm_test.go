@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWorkersValue(t *testing.T) {
+	for _, test := range []struct {
+		s       string
+		workers []int
+		err     bool
+	}{
+		{"0", []int{0}, false},
+		{"1 4 8", []int{1, 4, 8}, false},
+		{" 2   16 ", []int{2, 16}, false},
+		{"", nil, true},
+		{"   ", nil, true},
+		{"-1", nil, true},
+		{"1 x", nil, true},
+	} {
+		def := []int{0}
+		v := &WorkersValue{Workers: def}
+		err := v.Set(test.s)
+		if (err != nil) != test.err {
+			t.Fatalf("%q: error %v", test.s, err)
+		}
+		if test.err {
+			if !reflect.DeepEqual(v.Workers, def) { // keep default in case of error
+				t.Fatalf("%q: workers %v - expected %v", test.s, v.Workers, def)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(v.Workers, test.workers) {
+			t.Fatalf("%q: workers %v - expected %v", test.s, v.Workers, test.workers)
+		}
+	}
+}
+
+func TestPrmValue(t *testing.T) {
+	def := []Prm{{1, 100}, {10, 10}}
+	v := &PrmValue{Prms: def}
+
+	// keep parameters in case of error
+	for _, s := range []string{"", "1x100 10", "1x100 ax10", "1x100 10xa"} {
+		if err := v.Set(s); err == nil {
+			t.Fatalf("%q: error expected", s)
+		}
+		if !reflect.DeepEqual(v.Prms, []Prm{{1, 100}, {10, 10}}) {
+			t.Fatalf("%q: parameters %v - expected 1x100 10x10", s, v.Prms)
+		}
+	}
+
+	if err := v.Set("2x50"); err != nil {
+		t.Fatal(err)
+	}
+	if s := v.String(); s != "2x50" {
+		t.Fatalf("parameters %s - expected 2x50", s)
+	}
+	// default not overwritten
+	if !reflect.DeepEqual(def, []Prm{{1, 100}, {10, 10}}) {
+		t.Fatalf("default parameters %v - expected 1x100 10x10", def)
+	}
+}
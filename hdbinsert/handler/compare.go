@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+)
+
+// alpha is the significance level of the comparison.
+const alpha = 0.05
+
+// maxExact is the maximum number of samples (base plus candidate) the exact
+// Mann-Whitney U distribution is calculated for.
+const maxExact = 20
+
+// TestComparison is the structure used to provide the comparison of the durations of one test
+// (test type, batchCount, batchSize, workers, bufferSize and bulkSize) between the base and candidate run.
+type TestComparison struct {
+	Test                  string
+	BatchCount, BatchSize int
+	Workers               int `json:",omitempty"`
+	BufferSize            int
+	BulkSize              int
+	BaseSamples           []float64 // durations in seconds
+	CandidateSamples      []float64 // durations in seconds
+	BaseMedian            float64
+	CandidateMedian       float64
+	Delta                 float64 // change of the median duration in percent
+	P                     float64 // p-value (Mann-Whitney U test)
+	Significant           bool
+	Regression            bool
+}
+
+func (c *TestComparison) String() string {
+	s := fmt.Sprintf("%s %dx%d", path.Base(c.Test), c.BatchCount, c.BatchSize)
+	if c.Workers > 0 {
+		s = fmt.Sprintf("%s/%d", s, c.Workers)
+	}
+	s = fmt.Sprintf("%s (bufferSize %d bulkSize %d): %fs -> %fs", s, c.BufferSize, c.BulkSize, c.BaseMedian, c.CandidateMedian)
+	if !c.Significant {
+		return fmt.Sprintf("%s ~ (p=%.3f n=%d+%d)", s, c.P, len(c.BaseSamples), len(c.CandidateSamples))
+	}
+	s = fmt.Sprintf("%s %+.2f%% (p=%.3f n=%d+%d)", s, c.Delta, c.P, len(c.BaseSamples), len(c.CandidateSamples))
+	if c.Regression {
+		s += " regression"
+	}
+	return s
+}
+
+// Comparison is the structure used to provide the JSON based comparison response.
+type Comparison struct {
+	Base        string
+	Candidate   string
+	Threshold   float64 // regression threshold in percent
+	Tests       []*TestComparison
+	Regressions int
+	Error       string
+}
+
+func (c *Comparison) String() string {
+	if c.Error != "" {
+		return c.Error
+	}
+	return fmt.Sprintf("compare %s with %s: %d tests - %d regressions (threshold %.2f%%)", c.Candidate, c.Base, len(c.Tests), c.Regressions, c.Threshold)
+}
+
+// compareKey identifies the results of a test to be compared.
+type compareKey struct {
+	test                  string
+	batchCount, batchSize int
+	workers               int
+	bufferSize, bulkSize  int
+}
+
+// samples returns the durations in seconds of the successful results per test.
+func samples(results []*StoredResult) map[compareKey][]float64 {
+	m := map[compareKey][]float64{}
+	for _, r := range results {
+		if r.Result.Error != "" {
+			continue
+		}
+		k := compareKey{test: r.Result.Test, batchCount: r.Result.BatchCount, batchSize: r.Result.BatchSize, workers: r.Result.Workers, bufferSize: r.Result.BufferSize, bulkSize: r.Result.BulkSize}
+		m[k] = append(m[k], r.Result.Seconds)
+	}
+	return m
+}
+
+// compare compares the test results of the base and candidate run. A test is flagged as regression,
+// if the candidate median duration exceeds the base median duration by more than threshold percent
+// and the difference is statistically significant.
+func compare(base, candidate string, baseResults, candidateResults []*StoredResult, threshold float64) *Comparison {
+	c := &Comparison{Base: base, Candidate: candidate, Threshold: threshold, Tests: []*TestComparison{}}
+
+	baseSamples, candidateSamples := samples(baseResults), samples(candidateResults)
+
+	for k, x := range baseSamples {
+		y, ok := candidateSamples[k]
+		if !ok {
+			continue
+		}
+		tc := &TestComparison{
+			Test:             k.test,
+			BatchCount:       k.batchCount,
+			BatchSize:        k.batchSize,
+			Workers:          k.workers,
+			BufferSize:       k.bufferSize,
+			BulkSize:         k.bulkSize,
+			BaseSamples:      x,
+			CandidateSamples: y,
+			BaseMedian:       median(x),
+			CandidateMedian:  median(y),
+			P:                mannWhitney(x, y),
+		}
+		if tc.BaseMedian != 0 {
+			tc.Delta = (tc.CandidateMedian - tc.BaseMedian) / tc.BaseMedian * 100
+		}
+		tc.Significant = tc.P < alpha
+		tc.Regression = tc.Significant && tc.Delta > threshold
+		if tc.Regression {
+			c.Regressions++
+		}
+		c.Tests = append(c.Tests, tc)
+	}
+
+	sort.Slice(c.Tests, func(i, j int) bool {
+		ti, tj := c.Tests[i], c.Tests[j]
+		switch {
+		case ti.Test != tj.Test:
+			return ti.Test < tj.Test
+		case ti.BatchCount != tj.BatchCount:
+			return ti.BatchCount < tj.BatchCount
+		case ti.BatchSize != tj.BatchSize:
+			return ti.BatchSize < tj.BatchSize
+		case ti.Workers != tj.Workers:
+			return ti.Workers < tj.Workers
+		case ti.BufferSize != tj.BufferSize:
+			return ti.BufferSize < tj.BufferSize
+		default:
+			return ti.BulkSize < tj.BulkSize
+		}
+	})
+	return c
+}
+
+// median returns the median of the samples.
+func median(x []float64) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), x...)
+	sort.Float64s(s)
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
+
+// mannWhitney returns the two-sided p-value of the Mann-Whitney U test of the samples x and y.
+// For small samples without ties the exact U distribution is used, the normal approximation
+// (with tie and continuity correction) otherwise.
+func mannWhitney(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		v float64
+		x bool
+	}
+	s := make([]sample, 0, n1+n2)
+	for _, v := range x {
+		s = append(s, sample{v, true})
+	}
+	for _, v := range y {
+		s = append(s, sample{v, false})
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i].v < s[j].v })
+
+	// rank sum of x with average ranks for ties
+	n := len(s)
+	r1 := 0.0
+	tieSum := 0.0 // sum of t^3 - t over all groups of ties
+	for i := 0; i < n; {
+		j := i + 1
+		for j < n && s[j].v == s[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // average of ranks i+1 ... j
+		for k := i; k < j; k++ {
+			if s[k].x {
+				r1 += rank
+			}
+		}
+		t := float64(j - i)
+		tieSum += t*t*t - t
+		i = j
+	}
+	u := r1 - float64(n1*(n1+1))/2
+
+	if tieSum == 0 && n <= maxExact {
+		return exactP(int(math.Round(u)), n1, n2)
+	}
+
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * (float64(n+1) - tieSum/float64(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	z := (math.Abs(u-mu) - 0.5) / sigma
+	if z < 0 {
+		return 1
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactP returns the two-sided p-value of u for the exact U distribution of sample sizes n1 and n2.
+func exactP(u, n1, n2 int) float64 {
+	freq := uFreq(n1, n2, map[[2]int][]float64{})
+	total := 0.0
+	for _, f := range freq {
+		total += f
+	}
+	lower, upper := 0.0, 0.0 // P(U <= u), P(U >= u)
+	for i, f := range freq {
+		if i <= u {
+			lower += f
+		}
+		if i >= u {
+			upper += f
+		}
+	}
+	return math.Min(1, 2*math.Min(lower, upper)/total)
+}
+
+// uFreq returns the frequencies of the values 0 to n1*n2 of the U statistic for sample sizes n1 and n2.
+func uFreq(n1, n2 int, memo map[[2]int][]float64) []float64 {
+	if n1 == 0 || n2 == 0 {
+		return []float64{1}
+	}
+	k := [2]int{n1, n2}
+	if f, ok := memo[k]; ok {
+		return f
+	}
+	f := make([]float64, n1*n2+1)
+	// largest value in x: contributes n2 to U
+	for u, v := range uFreq(n1-1, n2, memo) {
+		f[u+n2] += v
+	}
+	// largest value in y: contributes 0 to U
+	for u, v := range uFreq(n1, n2-1, memo) {
+		f[u] += v
+	}
+	memo[k] = f
+	return f
+}
+
+// compareRuns compares the stored results of the test runs labeled base and candidate.
+func (s *resultStore) compareRuns(base, candidate string, threshold float64) *Comparison {
+	if base == "" || candidate == "" {
+		return &Comparison{Base: base, Candidate: candidate, Threshold: threshold, Error: "base and candidate run labels required"}
+	}
+	baseResults, err := s.list(&resultFilter{label: base})
+	if err != nil {
+		return &Comparison{Base: base, Candidate: candidate, Threshold: threshold, Error: err.Error()}
+	}
+	candidateResults, err := s.list(&resultFilter{label: candidate})
+	if err != nil {
+		return &Comparison{Base: base, Candidate: candidate, Threshold: threshold, Error: err.Error()}
+	}
+	return compare(base, candidate, baseResults, candidateResults, threshold)
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitney(t *testing.T) {
+	seq := func(start, end float64) []float64 {
+		s := []float64{}
+		for v := start; v <= end; v++ {
+			s = append(s, v)
+		}
+		return s
+	}
+
+	tests := []struct {
+		x, y []float64
+		p    float64
+	}{
+		{seq(1, 5), seq(6, 10), 2.0 / 252},    // exact
+		{seq(1, 3), seq(4, 6), 2.0 / 20},      // exact
+		{seq(1, 5), seq(1, 5), 1},             // ties
+		{seq(1, 15), seq(16, 30), 3.3918e-06}, // normal approximation
+	}
+
+	for _, test := range tests {
+		if p := mannWhitney(test.x, test.y); math.Abs(p-test.p) > test.p*1e-3 {
+			t.Fatalf("x %v y %v: p %g - expected %g", test.x, test.y, p, test.p)
+		}
+		// symmetry
+		if p1, p2 := mannWhitney(test.x, test.y), mannWhitney(test.y, test.x); math.Abs(p1-p2) > 1e-12 {
+			t.Fatalf("x %v y %v: p %g - p %g", test.x, test.y, p1, p2)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	results := func(test string, seconds ...float64) []*StoredResult {
+		l := make([]*StoredResult, len(seconds))
+		for i, s := range seconds {
+			l[i] = &StoredResult{Result: &TestResult{Test: test, BatchCount: 10, BatchSize: 1000, Seconds: s}}
+		}
+		return l
+	}
+
+	base := append(results(TestBulkSeq, 1.00, 1.01, 0.99, 1.02, 0.98), results(TestManySeq, 0.50, 0.51, 0.49, 0.52, 0.48)...)
+	candidate := append(results(TestBulkSeq, 1.20, 1.21, 1.19, 1.22, 1.18), results(TestManySeq, 0.505, 0.515, 0.495, 0.485, 0.475)...)
+	candidate = append(candidate, &StoredResult{Result: &TestResult{Test: TestBulkSeq, BatchCount: 10, BatchSize: 1000, Error: "failed"}})
+	// not compared: different buffer size
+	base = append(base, &StoredResult{Result: &TestResult{Test: TestBulkSeq, BatchCount: 10, BatchSize: 1000, BufferSize: 65536, Seconds: 0.5}})
+
+	c := compare("base", "candidate", base, candidate, 5)
+
+	if len(c.Tests) != 2 {
+		t.Fatalf("number of tests %d - expected %d", len(c.Tests), 2)
+	}
+	if c.Regressions != 1 {
+		t.Fatalf("number of regressions %d - expected %d", c.Regressions, 1)
+	}
+
+	bulk, many := c.Tests[0], c.Tests[1]
+	if bulk.Test != TestBulkSeq || !bulk.Regression || math.Abs(bulk.Delta-20) > 1e-9 || len(bulk.CandidateSamples) != 5 {
+		t.Fatalf("invalid comparison %s", bulk)
+	}
+	if many.Test != TestManySeq || many.Significant || many.Regression {
+		t.Fatalf("invalid comparison %s", many)
+	}
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+// ComparePath is the URL path of the test run comparison.
+const ComparePath = "/compare"
+
+// CompareHandler implements the http.Handler interface for the comparison of test runs.
+type CompareHandler struct {
+	log         logFunc
+	testHandler *TestHandler
+}
+
+// NewCompareHandler returns a new CompareHandler instance.
+func NewCompareHandler(log logFunc, testHandler *TestHandler) (*CompareHandler, error) {
+	return &CompareHandler{log: log, testHandler: testHandler}, nil
+}
+
+// ServeHTTP handles the URL path
+//
+//	/compare?base=<label>&candidate=<label>[&threshold=<percent>]
+func (h *CompareHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := newURLQuery(r)
+	result := h.testHandler.Compare(q.getString(urlQueryBase, ""), q.getString(urlQueryCandidate, ""), q.getFloat64(urlQueryThreshold, env.Threshold()))
+	h.log("%s", result)
+	json.NewEncoder(w).Encode(result) // ignore error
+}
@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+	"github.com/SAP/go-hdb/driver/dial"
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+// connectDialer dials database connections with a connect timeout independent of the connector (I/O) timeout.
+type connectDialer struct {
+	timeout time.Duration
+}
+
+func (d *connectDialer) DialContext(ctx context.Context, address string, options dial.DialerOptions) (net.Conn, error) {
+	options.Timeout = d.timeout
+	return dial.DefaultDialer.DialContext(ctx, address, options)
+}
+
+// connectorConfig is the go-hdb connector configuration shared by the test and database handler.
+type connectorConfig struct {
+	dsn              string
+	tlsConfig        *tls.Config // nil: TLS configuration of the DSN
+	dialer           dial.Dialer // nil: default dialer
+	timeout          time.Duration
+	locale           string
+	applicationName  string
+	sessionVariables driver.SessionVariables
+	fetchSize        int
+}
+
+// newEnvConnectorConfig returns the connector configuration defined by the command-line flags.
+func newEnvConnectorConfig() (*connectorConfig, error) {
+	tlsConfig, err := newTLSConfig(env.TLSRootCAFile(), env.TLSServerName(), env.TLSInsecureSkipVerify())
+	if err != nil {
+		return nil, err
+	}
+	c := &connectorConfig{
+		dsn:              env.DSN(),
+		tlsConfig:        tlsConfig,
+		timeout:          time.Duration(env.IOTimeout()) * time.Second,
+		locale:           env.Locale(),
+		applicationName:  env.ApplicationName(),
+		sessionVariables: driver.SessionVariables(env.SessionVariables().Vars),
+		fetchSize:        env.FetchSize(),
+	}
+	if env.ConnectTimeout() > 0 {
+		c.dialer = &connectDialer{timeout: time.Duration(env.ConnectTimeout()) * time.Second}
+	}
+	return c, nil
+}
+
+// newTLSConfig returns the TLS configuration or nil if none of the TLS parameters is set.
+func newTLSConfig(rootCAFile, serverName string, insecureSkipVerify bool) (*tls.Config, error) {
+	if rootCAFile == "" && serverName == "" && !insecureSkipVerify {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{ServerName: serverName, InsecureSkipVerify: insecureSkipVerify}
+	if rootCAFile != "" {
+		rootPEM, err := os.ReadFile(rootCAFile)
+		if err != nil {
+			return nil, err
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(rootPEM) {
+			return nil, fmt.Errorf("failed to parse root certificate - filename: %s", rootCAFile)
+		}
+		tlsConfig.RootCAs = certPool
+	}
+	return tlsConfig, nil
+}
+
+// connector returns a new go-hdb connector with bulkSize and bufferSize (driver defaults if zero)
+// and fetchSize (connector configuration fetch size if zero).
+func (c *connectorConfig) connector(bulkSize, bufferSize, fetchSize int) (*driver.Connector, error) {
+	attrs := map[string]interface{}{"dsn": c.dsn}
+	if bulkSize != 0 {
+		attrs["bulkSize"] = bulkSize
+	}
+	if bufferSize != 0 {
+		attrs["bufferSize"] = bufferSize
+	}
+	connector, err := driver.NewConnector(attrs)
+	if err != nil {
+		return nil, err
+	}
+
+	if fetchSize == 0 {
+		fetchSize = c.fetchSize
+	}
+	if err := connector.SetFetchSize(fetchSize); err != nil {
+		return nil, err
+	}
+	if c.tlsConfig != nil {
+		if err := connector.SetTLSConfig(c.tlsConfig.Clone()); err != nil {
+			return nil, err
+		}
+	}
+	if c.dialer != nil {
+		if err := connector.SetDialer(c.dialer); err != nil {
+			return nil, err
+		}
+	}
+	if c.timeout > 0 {
+		if err := connector.SetTimeout(c.timeout); err != nil {
+			return nil, err
+		}
+	}
+	if c.locale != "" {
+		connector.SetLocale(c.locale)
+	}
+	if c.applicationName != "" {
+		if err := connector.SetApplicationName(c.applicationName); err != nil {
+			return nil, err
+		}
+	}
+	if len(c.sessionVariables) != 0 {
+		if err := connector.SetSessionVariables(c.sessionVariables); err != nil {
+			return nil, err
+		}
+	}
+	return connector, nil
+}
@@ -5,6 +5,7 @@
 package handler
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -12,61 +13,61 @@ import (
 )
 
 // createSchema creates a schema on the database.
-func createSchema(db *sql.DB, name string) error {
-	_, err := db.Exec(fmt.Sprintf("create schema %s", driver.Identifier(name)))
+func createSchema(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("create schema %s", driver.Identifier(name)))
 	return err
 }
 
 // dropSchema drops a schema from the database even if the schema is not empty.
-func dropSchema(db *sql.DB, name string, cascade bool) error {
+func dropSchema(ctx context.Context, db *sql.DB, name string, cascade bool) error {
 	var stmt string
 	if cascade {
 		stmt = fmt.Sprintf("drop schema %s cascade", driver.Identifier(name))
 	} else {
 		stmt = fmt.Sprintf("drop schema %s", driver.Identifier(name))
 	}
-	_, err := db.Exec(stmt)
+	_, err := db.ExecContext(ctx, stmt)
 	return err
 }
 
-// createTable creates a table from the databasesde.
-func createTable(db *sql.DB, schemaName, tableName string) error {
-	_, err := db.Exec(fmt.Sprintf("create column table %s.%s (%s)", driver.Identifier(schemaName), driver.Identifier(tableName), columns))
+// createTable creates a table with column definitions columns on the database.
+func createTable(ctx context.Context, db *sql.DB, schemaName, tableName, columns string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("create column table %s.%s (%s)", driver.Identifier(schemaName), driver.Identifier(tableName), columns))
 	return err
 }
 
 // dropTable drops a table from the databases.
-func dropTable(db *sql.DB, schemaName, tableName string) error {
-	_, err := db.Exec(fmt.Sprintf("drop table %s.%s", driver.Identifier(schemaName), driver.Identifier(tableName)))
+func dropTable(ctx context.Context, db *sql.DB, schemaName, tableName string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("drop table %s.%s", driver.Identifier(schemaName), driver.Identifier(tableName)))
 	return err
 }
 
 // existTable returns true if the table exists in schema.
-func existTable(db *sql.DB, schemaName, tableName string) (bool, error) {
+func existTable(ctx context.Context, db *sql.DB, schemaName, tableName string) (bool, error) {
 	numTables := 0
-	if err := db.QueryRow(fmt.Sprintf("select count(*) from sys.tables where schema_name = '%s' and table_name = '%s'", schemaName, tableName)).Scan(&numTables); err != nil {
+	if err := db.QueryRowContext(ctx, fmt.Sprintf("select count(*) from sys.tables where schema_name = '%s' and table_name = '%s'", schemaName, tableName)).Scan(&numTables); err != nil {
 		return false, err
 	}
 	return numTables != 0, nil
 }
 
 // ensureTable creates a table if it does not exist. If drop is set, an existing table would be dropped before recreated.
-func ensureTable(db *sql.DB, schemaName, tableName string, drop bool) error {
-	exist, err := existTable(db, schemaName, tableName)
+func ensureTable(ctx context.Context, db *sql.DB, schemaName, tableName, columns string, drop bool) error {
+	exist, err := existTable(ctx, db, schemaName, tableName)
 	if err != nil {
 		return err
 	}
 
 	switch {
 	case exist && drop:
-		if err := dropTable(db, schemaName, tableName); err != nil {
+		if err := dropTable(ctx, db, schemaName, tableName); err != nil {
 			return err
 		}
-		if err := createTable(db, schemaName, tableName); err != nil {
+		if err := createTable(ctx, db, schemaName, tableName, columns); err != nil {
 			return err
 		}
 	case !exist:
-		if err := createTable(db, schemaName, tableName); err != nil {
+		if err := createTable(ctx, db, schemaName, tableName, columns); err != nil {
 			return err
 		}
 	}
@@ -74,8 +75,8 @@ func ensureTable(db *sql.DB, schemaName, tableName string, drop bool) error {
 }
 
 // deleteRows deletes all records in the database table.
-func deleteRows(db *sql.DB, schemaName, tableName string) (int64, error) {
-	result, err := db.Exec(fmt.Sprintf("delete from %s.%s", driver.Identifier(schemaName), driver.Identifier(tableName)))
+func deleteRows(ctx context.Context, db *sql.DB, schemaName, tableName string) (int64, error) {
+	result, err := db.ExecContext(ctx, fmt.Sprintf("delete from %s.%s", driver.Identifier(schemaName), driver.Identifier(tableName)))
 	if err != nil {
 		return 0, err
 	}
@@ -87,12 +88,28 @@ func deleteRows(db *sql.DB, schemaName, tableName string) (int64, error) {
 }
 
 // countRows returns the number of rows in the database table.
-func countRows(db *sql.DB, schemaName, tableName string) (int64, error) {
+func countRows(ctx context.Context, db *sql.DB, schemaName, tableName string) (int64, error) {
 	var numRow int64
 
-	err := db.QueryRow(fmt.Sprintf("select count(*) from %s.%s", driver.Identifier(schemaName), driver.Identifier(tableName))).Scan(&numRow)
+	err := db.QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s.%s", driver.Identifier(schemaName), driver.Identifier(tableName))).Scan(&numRow)
 	if err != nil {
 		return 0, err
 	}
 	return numRow, nil
 }
+
+// hdbVersion returns the version of the database server.
+func hdbVersion(ctx context.Context, db *sql.DB) (string, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	var hdbVersion string
+	err = conn.Raw(func(driverConn interface{}) error {
+		hdbVersion = driverConn.(*driver.Conn).ServerInfo().Version.String()
+		return nil
+	})
+	return hdbVersion, err
+}
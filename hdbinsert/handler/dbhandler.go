@@ -13,11 +13,8 @@ import (
 	"strings"
 
 	"github.com/SAP/go-hdb/driver"
-	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
 )
 
-const columns = "DEVICEID INTEGER, TEMPERATUR DOUBLE, HUMIDITY DOUBLE, CO2 DOUBLE, CO DOUBLE, LPG DOUBLE, SMOKE DOUBLE, PRESENCE DOUBLE, LIGHT DOUBLE, SOUND DOUBLE"
-
 // Database operation URL paths.
 const (
 	CmdCountRows    = "/db/countRows"
@@ -77,7 +74,7 @@ type dbFunc struct {
 	Command string
 	Obj     dbObj
 	Op      dbOp
-	f       func(q *urlQuery, r *DBResult) error
+	f       func(ctx context.Context, q *urlQuery, r *DBResult) error
 }
 
 // DBHandler implements the http.Handler interface for database operations.
@@ -90,11 +87,19 @@ type DBHandler struct {
 
 // NewDBHandler returns a new DBHandler instance.
 func NewDBHandler(log logFunc) (*DBHandler, error) {
-	connector, err := driver.NewDSNConnector(env.DSN())
+	table, err := newEnvTable()
+	if err != nil {
+		return nil, err
+	}
+	connConfig, err := newEnvConnectorConfig()
+	if err != nil {
+		return nil, err
+	}
+	connector, err := connConfig.connector(0, 0, 0)
 	if err != nil {
 		return nil, err
 	}
-	h := &DBHandler{log: log, db: sql.OpenDB(connector), columns: columns}
+	h := &DBHandler{log: log, db: sql.OpenDB(connector), columns: table.columnDefs()}
 	h.dbFuncs = map[string]*dbFunc{
 		CmdCountRows:    {Command: CmdCountRows, Obj: objTable, Op: opCountRows, f: h.countRows},
 		CmdDeleteRows:   {Command: CmdDeleteRows, Obj: objTable, Op: opDeleteRows, f: h.deleteRows},
@@ -110,16 +115,14 @@ func NewDBHandler(log logFunc) (*DBHandler, error) {
 func (h DBHandler) DriverVersion() string { return driver.DriverVersion }
 
 // HDBVersion returns the hdb version.
-func (h DBHandler) HDBVersion() string {
-	conn, err := h.db.Conn(context.Background())
+func (h DBHandler) HDBVersion() string { return h.hdbVersionContext(context.Background()) }
+
+// hdbVersionContext returns the hdb version querying the database with context ctx.
+func (h DBHandler) hdbVersionContext(ctx context.Context) string {
+	hdbVersion, err := hdbVersion(ctx, h.db)
 	if err != nil {
 		return err.Error()
 	}
-	var hdbVersion string
-	conn.Raw(func(driverConn interface{}) error {
-		hdbVersion = driverConn.(*driver.Conn).ServerInfo().Version.String()
-		return nil
-	})
 	return hdbVersion
 }
 
@@ -150,13 +153,25 @@ func (h *DBHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		e.Encode(result) // ignore error
 	}()
 
-	var err error
+	q := newURLQuery(r)
+
+	timeout, err := q.getValidDuration(urlQueryTimeout, 0)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	ctx := r.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	dbFunc, ok := h.dbFuncs[command]
 	if ok {
 		result.DbObj = dbFunc.Obj
 		result.DbOp = dbFunc.Op
-		err = dbFunc.f(newURLQuery(r), result)
+		err = dbFunc.f(ctx, q, result)
 	} else {
 		err = fmt.Errorf("Invalid command %s", command)
 	}
@@ -177,14 +192,14 @@ func getSchemaTableNames(q *urlQuery) (string, string, error) {
 	return schemaName, tableName, nil
 }
 
-func (h *DBHandler) countRows(q *urlQuery, r *DBResult) error {
+func (h *DBHandler) countRows(ctx context.Context, q *urlQuery, r *DBResult) error {
 	schemaName, tableName, err := getSchemaTableNames(q)
 	if err != nil {
 		return err
 	}
 
 	r.ObjName = strings.Join([]string{schemaName, tableName}, ".")
-	numRow, err := countRows(h.db, schemaName, tableName)
+	numRow, err := countRows(ctx, h.db, schemaName, tableName)
 	if err != nil {
 		return err
 	}
@@ -192,14 +207,14 @@ func (h *DBHandler) countRows(q *urlQuery, r *DBResult) error {
 	return nil
 }
 
-func (h *DBHandler) deleteRows(q *urlQuery, r *DBResult) error {
+func (h *DBHandler) deleteRows(ctx context.Context, q *urlQuery, r *DBResult) error {
 	schemaName, tableName, err := getSchemaTableNames(q)
 	if err != nil {
 		return err
 	}
 
 	r.ObjName = strings.Join([]string{schemaName, tableName}, ".")
-	numRow, err := deleteRows(h.db, schemaName, tableName)
+	numRow, err := deleteRows(ctx, h.db, schemaName, tableName)
 	if err != nil {
 		return err
 	}
@@ -207,51 +222,51 @@ func (h *DBHandler) deleteRows(q *urlQuery, r *DBResult) error {
 	return nil
 }
 
-func (h *DBHandler) createTable(q *urlQuery, r *DBResult) error {
+func (h *DBHandler) createTable(ctx context.Context, q *urlQuery, r *DBResult) error {
 	schemaName, tableName, err := getSchemaTableNames(q)
 	if err != nil {
 		return err
 	}
 
 	r.ObjName = strings.Join([]string{schemaName, tableName}, ".")
-	if err := createTable(h.db, schemaName, tableName); err != nil {
+	if err := createTable(ctx, h.db, schemaName, tableName, h.columns); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (h *DBHandler) dropTable(q *urlQuery, r *DBResult) error {
+func (h *DBHandler) dropTable(ctx context.Context, q *urlQuery, r *DBResult) error {
 	schemaName, tableName, err := getSchemaTableNames(q)
 	if err != nil {
 		return err
 	}
 
 	r.ObjName = strings.Join([]string{schemaName, tableName}, ".")
-	if err := dropTable(h.db, schemaName, tableName); err != nil {
+	if err := dropTable(ctx, h.db, schemaName, tableName); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (h *DBHandler) createSchema(q *urlQuery, r *DBResult) error {
+func (h *DBHandler) createSchema(ctx context.Context, q *urlQuery, r *DBResult) error {
 	schemaName, err := q.get(urlQuerySchemaName)
 	if err != nil {
 		return err
 	}
 	r.ObjName = schemaName
-	if err := createSchema(h.db, schemaName); err != nil {
+	if err := createSchema(ctx, h.db, schemaName); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (h *DBHandler) dropSchema(q *urlQuery, r *DBResult) error {
+func (h *DBHandler) dropSchema(ctx context.Context, q *urlQuery, r *DBResult) error {
 	schemaName, err := q.get(urlQuerySchemaName)
 	if err != nil {
 		return err
 	}
 	r.ObjName = schemaName
-	if err := dropSchema(h.db, schemaName, false); err != nil {
+	if err := dropSchema(ctx, h.db, schemaName, false); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,216 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// DML operations executed by the bulk and many tests.
+const (
+	dmlInsert = iota
+	dmlUpsert
+	dmlUpdate
+	dmlDelete
+)
+
+type dmlOp int
+
+func (op dmlOp) String() string {
+	switch op {
+	case dmlUpsert:
+		return "upsert"
+	case dmlUpdate:
+		return "update"
+	case dmlDelete:
+		return "delete"
+	default:
+		return "insert"
+	}
+}
+
+// keyed returns true if the operation is identifying the table rows by the primary key.
+// Tables of keyed operations are created with primary key and are filled before the test.
+func (op dmlOp) keyed() bool { return op != dmlInsert }
+
+// check returns an error if the operation cannot be executed on table t. Keyed operations require a key column
+// containing the row index and updates require at least one non key column.
+func (op dmlOp) check(t *table) error {
+	if !op.keyed() {
+		return nil
+	}
+	if err := t.checkKey(); err != nil {
+		return err
+	}
+	if op == dmlUpdate && t.numColumn() < 2 {
+		return fmt.Errorf("%s: table without non key columns", op)
+	}
+	return nil
+}
+
+// query returns the query of the operation for table tableName.
+func (op dmlOp) query(schemaName, tableName string, t *table, bulk bool) string {
+	var query string
+	switch op {
+	case dmlUpsert:
+		query = getUpsertQuery(schemaName, tableName, t.numColumn())
+	case dmlUpdate:
+		names := make([]string, 0, t.numColumn()-1)
+		for _, c := range t.columns[1:] {
+			names = append(names, driver.Identifier(c.Name).String()+" = ?")
+		}
+		query = fmt.Sprintf("update %s.%s set %s where %s = ?", driver.Identifier(schemaName), driver.Identifier(tableName), strings.Join(names, ", "), driver.Identifier(t.key().Name))
+	case dmlDelete:
+		query = fmt.Sprintf("delete from %s.%s where %s = ?", driver.Identifier(schemaName), driver.Identifier(tableName), driver.Identifier(t.key().Name))
+	default:
+		query = getInsertQuery(schemaName, tableName, t.numColumn())
+	}
+	if bulk {
+		return "bulk " + query
+	}
+	return query
+}
+
+// args returns the statement arguments of the operation for a table row.
+func (op dmlOp) args(row []interface{}) []interface{} {
+	switch op {
+	case dmlUpdate:
+		// non key columns followed by key
+		args := make([]interface{}, 0, len(row))
+		args = append(args, row[1:]...)
+		return append(args, row[0])
+	case dmlDelete:
+		return row[:1]
+	default:
+		return row
+	}
+}
+
+// rowSize returns the payload size per row of the operation.
+func (op dmlOp) rowSize(t *table) int {
+	if op == dmlDelete {
+		return t.key().size
+	}
+	return t.rowSize
+}
+
+// rows returns the statement arguments of the operation for the rows of batch i.
+func (op dmlOp) rows(t *table, seed int64, i, size int) [][]interface{} {
+	rows := t.rows(seed, i, size)
+	for j, row := range rows {
+		rows[j] = op.args(row)
+	}
+	return rows
+}
+
+func getUpsertQuery(schemaName, tableName string, numColumn int) string {
+	return fmt.Sprintf("upsert %s.%s values (%s) with primary key", driver.Identifier(schemaName), driver.Identifier(tableName), placeholders(numColumn))
+}
+
+// dmlTable returns the name of the test table of operation op. Keyed operations are using table <tableName>_KEY
+// (<tableName>_KEY_<n> for separate tables), as an existing insert test table without primary key cannot be used
+// by keyed operations in case the table is not dropped before the test.
+func (h *TestHandler) dmlTable(op dmlOp, i int, separate bool) string {
+	tableName := h.tableName
+	if op.keyed() {
+		tableName += "_KEY"
+	}
+	if separate {
+		return fmt.Sprintf("%s_%d", tableName, i)
+	}
+	return tableName
+}
+
+// prefillSeed returns the seed of the rows the table of keyed operations is filled with before the test.
+// The seed differs from the test seed, so that the updated and upserted rows differ from the existing rows.
+func prefillSeed(seed int64) int64 { return ^seed }
+
+// prefillRows returns the rows of batch i the table of keyed operations is filled with before the test.
+// For upserts only the first half of the batch rows is prefilled, so that the upsert test is executing
+// updates of existing rows as well as inserts of new rows.
+func (op dmlOp) prefillRows(t *table, seed int64, i, size int) [][]interface{} {
+	rows := t.rows(prefillSeed(seed), i, size)
+	if op == dmlUpsert {
+		return rows[:size/2]
+	}
+	return rows
+}
+
+// getDeleteRangeQuery returns the query deleting the rows of a key range.
+func getDeleteRangeQuery(schemaName, tableName, key string) string {
+	return fmt.Sprintf("delete from %s.%s where %s >= ? and %s < ?", driver.Identifier(schemaName), driver.Identifier(tableName), driver.Identifier(key), driver.Identifier(key))
+}
+
+// prepareTable ensures the existence of the test table tableName. For keyed operations
+// the table is created with primary key and the rows of the batches are upserted (see prefillRows),
+// so that the table content does not depend on previous test runs. For upserts the rows of the batches
+// are deleted before, so that the not prefilled rows do not exist.
+func (h *TestHandler) prepareTable(ctx context.Context, db *sql.DB, prm *testPrm, op dmlOp, tableName string, batches ...int) error {
+	if !op.keyed() {
+		return ensureTable(ctx, db, h.schemaName, tableName, h.table.columnDefs(), prm.drop)
+	}
+
+	if err := op.check(h.table); err != nil {
+		return err
+	}
+	if err := ensureTable(ctx, db, h.schemaName, tableName, h.table.keyColumnDefs(), prm.drop); err != nil {
+		return err
+	}
+
+	stmt, err := db.PrepareContext(ctx, getUpsertQuery(h.schemaName, tableName, h.table.numColumn()))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var deleteStmt *sql.Stmt
+	if op == dmlUpsert {
+		if deleteStmt, err = db.PrepareContext(ctx, getDeleteRangeQuery(h.schemaName, tableName, h.table.key().Name)); err != nil {
+			return err
+		}
+		defer deleteStmt.Close()
+	}
+
+	for _, i := range batches {
+		if deleteStmt != nil {
+			if _, err := deleteStmt.ExecContext(ctx, i*prm.batchSize, (i+1)*prm.batchSize); err != nil {
+				return err
+			}
+		}
+		rows := op.prefillRows(h.table, prm.seed, i, prm.batchSize)
+		if len(rows) == 0 {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchRange returns the batch numbers 0 to n-1.
+func batchRange(n int) []int {
+	r := make([]int, n)
+	for i := range r {
+		r[i] = i
+	}
+	return r
+}
+
+// dmlFunc is a test function executing a DML operation.
+type dmlFunc func(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm, op dmlOp) (time.Duration, error)
+
+// with returns the test function executing operation op.
+func (f dmlFunc) with(op dmlOp) testFunc {
+	return func(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+		return f(ctx, m, db, prm, op)
+	}
+}
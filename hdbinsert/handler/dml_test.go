@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+func TestDML(t *testing.T) {
+	tab, err := newTable([]env.Column{
+		{Name: "ID", Type: "INTEGER"},
+		{Name: "VALUE", Type: "DOUBLE"},
+		{Name: "NAME", Type: "NVARCHAR(20)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row := []interface{}{1, 2.0, "abc"}
+
+	tests := []struct {
+		op      dmlOp
+		bulk    bool
+		query   string
+		args    []interface{}
+		rowSize int
+	}{
+		{dmlInsert, true, "bulk insert into S.T values (?, ?, ?)", row, 32},
+		{dmlUpsert, false, "upsert S.T values (?, ?, ?) with primary key", row, 32},
+		{dmlUpdate, true, "bulk update S.T set VALUE = ?, NAME = ? where ID = ?", []interface{}{2.0, "abc", 1}, 32},
+		{dmlDelete, false, "delete from S.T where ID = ?", []interface{}{1}, 4},
+	}
+
+	for _, test := range tests {
+		if query := test.op.query("S", "T", tab, test.bulk); query != test.query {
+			t.Fatalf("%s: query %s - expected %s", test.op, query, test.query)
+		}
+		if args := test.op.args(row); !reflect.DeepEqual(args, test.args) {
+			t.Fatalf("%s: args %v - expected %v", test.op, args, test.args)
+		}
+		if rowSize := test.op.rowSize(tab); rowSize != test.rowSize {
+			t.Fatalf("%s: row size %d - expected %d", test.op, rowSize, test.rowSize)
+		}
+	}
+
+	if defs := "ID INTEGER, VALUE DOUBLE, NAME NVARCHAR(20), primary key (ID)"; tab.keyColumnDefs() != defs {
+		t.Fatalf("column definitions %s - expected %s", tab.keyColumnDefs(), defs)
+	}
+}
+
+func TestDMLTable(t *testing.T) {
+	h := &TestHandler{tableName: "T"}
+	for _, test := range []struct {
+		op        dmlOp
+		i         int
+		separate  bool
+		tableName string
+	}{
+		{dmlInsert, 0, false, "T"},
+		{dmlInsert, 2, true, "T_2"},
+		{dmlUpsert, 0, false, "T_KEY"},
+		{dmlUpdate, 2, true, "T_KEY_2"},
+	} {
+		if tableName := h.dmlTable(test.op, test.i, test.separate); tableName != test.tableName {
+			t.Fatalf("%s: table name %s - expected %s", test.op, tableName, test.tableName)
+		}
+	}
+
+	tab, err := newTable([]env.Column{{Name: "ID", Type: "INTEGER"}, {Name: "VALUE", Type: "DOUBLE"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// prefilled rows of upserts: first half of the batch rows
+	for _, test := range []struct {
+		op      dmlOp
+		numRow  int
+		prefill int
+	}{
+		{dmlUpsert, 10, 5},
+		{dmlUpsert, 1, 0},
+		{dmlUpdate, 10, 10},
+		{dmlDelete, 10, 10},
+	} {
+		if rows := test.op.prefillRows(tab, 42, 1, test.numRow); len(rows) != test.prefill {
+			t.Fatalf("%s: %d prefilled rows - expected %d", test.op, len(rows), test.prefill)
+		}
+	}
+
+	// prefilled rows: same keys - different values
+	for _, seed := range []int64{0, 42} {
+		rows, prefill := tab.rows(seed, 1, 10), tab.rows(prefillSeed(seed), 1, 10)
+		for j := range rows {
+			if rows[j][0] != prefill[j][0] || rows[j][1] == prefill[j][1] {
+				t.Fatalf("seed %d row %d: %v - prefilled %v", seed, j, rows[j], prefill[j])
+			}
+		}
+	}
+}
+
+func TestDMLCheck(t *testing.T) {
+	keyOnly, err := newTable([]env.Column{{Name: "ID", Type: "INTEGER"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		op  dmlOp
+		err bool
+	}{
+		{dmlInsert, false},
+		{dmlUpsert, false},
+		{dmlUpdate, true}, // no non key column to be updated
+		{dmlDelete, false},
+	} {
+		if err := test.op.check(keyOnly); (err != nil) != test.err {
+			t.Fatalf("%s: check error %v", test.op, err)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// Result formats.
+const (
+	formatJSON      = "json"
+	formatCSV       = "csv"
+	formatBenchstat = "benchstat" // go benchmark text format
+	formatJUnit     = "junit"
+)
+
+// formatMediaTypes maps the media types of the Accept header to the result formats.
+// Browsers (text/html) get JSON.
+var formatMediaTypes = map[string]string{
+	"text/html":        formatJSON,
+	"application/json": formatJSON,
+	"text/csv":         formatCSV,
+	"text/plain":       formatBenchstat,
+	"application/xml":  formatJUnit,
+	"text/xml":         formatJUnit,
+}
+
+// formatContentTypes maps the result formats to the HTTP response content types.
+var formatContentTypes = map[string]string{
+	formatJSON:      "application/json",
+	formatCSV:       "text/csv; charset=utf-8",
+	formatBenchstat: "text/plain; charset=utf-8",
+	formatJUnit:     "application/xml; charset=utf-8",
+}
+
+// ParseResultFormat returns the result format s (json, csv, benchstat or junit) or an error if s is not supported.
+func ParseResultFormat(s string) (string, error) {
+	format := strings.ToLower(s)
+	if _, ok := formatContentTypes[format]; !ok {
+		return "", fmt.Errorf("invalid format %s (json, csv, benchstat or junit)", s)
+	}
+	return format, nil
+}
+
+// resultFormat returns the result format requested by the URL query parameter format or, if not set,
+// by the first supported media type of the Accept header. The default format is JSON.
+func resultFormat(r *http.Request, q *urlQuery) (string, error) {
+	if format := q.getString(urlQueryFormat, ""); format != "" {
+		return ParseResultFormat(format)
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		if format, ok := formatMediaTypes[mediaType]; ok {
+			return format, nil
+		}
+	}
+	return formatJSON, nil
+}
+
+// writeResults writes the test results in format as HTTP response.
+func writeResults(w http.ResponseWriter, format string, results []*TestResult) error {
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	return WriteResults(w, format, results)
+}
+
+// WriteResults writes the test results in format (see ParseResultFormat) to w.
+func WriteResults(w io.Writer, format string, results []*TestResult) error {
+	format, err := ParseResultFormat(format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case formatCSV:
+		return writeCSV(w, results)
+	case formatBenchstat:
+		return writeBenchstat(w, results)
+	case formatJUnit:
+		return writeJUnit(w, results)
+	default:
+		return json.NewEncoder(w).Encode(results)
+	}
+}
+
+// resultParameters returns the test parameters of a result (e.g. 10x1000 or 10x1000/workers=4).
+// Bulk sizes different from the batch size and non default buffer sizes (see sweep) are added as well.
+func resultParameters(r *TestResult) string {
+	s := fmt.Sprintf("%dx%d", r.BatchCount, r.BatchSize)
+	if r.Workers > 0 {
+		s = fmt.Sprintf("%s/workers=%d", s, r.Workers)
+	}
+	if r.BulkSize != 0 && r.BulkSize != r.BatchSize {
+		s = fmt.Sprintf("%s/bulkSize=%d", s, r.BulkSize)
+	}
+	if r.BufferSize != 0 && r.BufferSize != driver.DefaultBufferSize {
+		s = fmt.Sprintf("%s/bufferSize=%d", s, r.BufferSize)
+	}
+	return s
+}
+
+var csvHeader = []string{
+	"RunID", "Label", "Test", "DriverVersion", "HDBVersion", "BatchCount", "BatchSize", "BulkSize", "BufferSize", "FetchSize", "Workers", "Seed",
+	"NumRow", "NumFailedRow", "Seconds", "RowsPerSecond", "MBPerSecond", "P50", "P99", "Error",
+}
+
+// writeCSV writes one line per test result.
+func writeCSV(w io.Writer, results []*TestResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	itoa := strconv.Itoa
+	ftoa := func(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+	for _, r := range results {
+		var p50, p99 time.Duration
+		if r.Latency != nil {
+			p50, p99 = r.Latency.P50, r.Latency.P99
+		}
+		record := []string{
+			strconv.FormatInt(r.RunID, 10), r.Label, path.Base(r.Test), r.DriverVersion, r.HDBVersion,
+			itoa(r.BatchCount), itoa(r.BatchSize), itoa(r.BulkSize), itoa(r.BufferSize), itoa(r.FetchSize), itoa(r.Workers), strconv.FormatInt(r.Seed, 10),
+			strconv.FormatInt(r.NumRow, 10), strconv.FormatInt(r.NumFailedRow, 10), ftoa(r.Seconds), ftoa(r.RowsPerSecond), ftoa(r.MBPerSecond),
+			ftoa(p50.Seconds()), ftoa(p99.Seconds()), r.Error,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeBenchstat writes the successful test results in the go benchmark text format, so that the results
+// can be compared by benchstat. Repeated test runs result in one benchmark line per measured run.
+func writeBenchstat(w io.Writer, results []*TestResult) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "goos: %s\ngoarch: %s\npkg: hdbinsert\n", runtime.GOOS, runtime.GOARCH)
+
+	// configuration lines apply to the following benchmark lines
+	config := map[string]string{}
+	setConfig := func(key, value string) {
+		if value != "" && config[key] != value {
+			config[key] = value
+			fmt.Fprintf(bw, "%s: %s\n", key, value)
+		}
+	}
+
+	for _, r := range results {
+		if r.Error != "" {
+			continue
+		}
+		setConfig("label", r.Label)
+		setConfig("driver", r.DriverVersion)
+		setConfig("hdb", strings.ReplaceAll(r.HDBVersion, " ", "_"))
+
+		name := fmt.Sprintf("Benchmark%s/%s", path.Base(r.Test), resultParameters(r))
+		seconds := []float64{r.Seconds}
+		if r.Stats != nil && len(r.Stats.Seconds) != 0 {
+			seconds = r.Stats.Seconds
+		}
+		for _, s := range seconds {
+			fmt.Fprintf(bw, "%s\t1\t%.0f ns/op", name, s*1e9)
+			if s > 0 { // rows and bytes of the last run
+				fmt.Fprintf(bw, "\t%.2f rows/s\t%.2f MB/s", float64(r.NumRow)/s, r.MBPerSecond*r.Seconds/s)
+			}
+			fmt.Fprintln(bw)
+		}
+	}
+	return bw.Flush()
+}
+
+// JUnit XML structures.
+type (
+	junitTestSuites struct {
+		XMLName xml.Name         `xml:"testsuites"`
+		Suites  []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name       string          `xml:"name,attr"`
+		Tests      int             `xml:"tests,attr"`
+		Failures   int             `xml:"failures,attr"`
+		Time       string          `xml:"time,attr"`
+		Properties []junitProperty `xml:"properties>property,omitempty"`
+		Cases      []junitTestCase `xml:"testcase"`
+	}
+	junitProperty struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	}
+	junitTestCase struct {
+		ClassName string        `xml:"classname,attr"`
+		Name      string        `xml:"name,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitFailure `xml:"failure,omitempty"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+)
+
+// writeJUnit writes the test results as JUnit XML test suite with one test case per test result.
+func writeJUnit(w io.Writer, results []*TestResult) error {
+	suite := junitTestSuite{Name: "hdbinsert", Tests: len(results), Cases: []junitTestCase{}}
+	seconds := 0.0
+	versions := map[string]bool{}
+	for _, r := range results {
+		seconds += r.Seconds
+		tc := junitTestCase{
+			ClassName: "hdbinsert." + path.Base(r.Test),
+			Name:      resultParameters(r),
+			Time:      fmt.Sprintf("%.3f", r.Seconds),
+			SystemOut: r.String(),
+		}
+		if r.Error != "" {
+			tc.Failure = &junitFailure{Message: r.Error, Text: r.Error}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+
+		for _, p := range []junitProperty{{"label", r.Label}, {"driverVersion", r.DriverVersion}, {"hdbVersion", r.HDBVersion}} {
+			if key := p.Name + "=" + p.Value; p.Value != "" && !versions[key] {
+				versions[key] = true
+				suite.Properties = append(suite.Properties, p)
+			}
+		}
+	}
+	suite.Time = fmt.Sprintf("%.3f", seconds)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
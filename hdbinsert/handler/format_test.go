@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResultFormat(t *testing.T) {
+	tests := []struct {
+		url, accept string
+		format      string
+		err         bool
+	}{
+		{"/test/BulkSeq", "", formatJSON, false},
+		{"/test/BulkSeq?format=CSV", "", formatCSV, false},
+		{"/test/BulkSeq?format=junit", "text/csv", formatJUnit, false},
+		{"/test/BulkSeq?format=yaml", "", "", true},
+		{"/test/BulkSeq", "text/csv", formatCSV, false},
+		{"/test/BulkSeq", "application/foo, text/plain;q=0.9", formatBenchstat, false},
+		{"/test/BulkSeq", "application/xml", formatJUnit, false},
+		{"/test/BulkSeq", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", formatJSON, false}, // browser
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.url, nil)
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		format, err := resultFormat(r, newURLQuery(r))
+		if (err != nil) != test.err {
+			t.Fatalf("%s accept %s: unexpected error %v", test.url, test.accept, err)
+		}
+		if format != test.format {
+			t.Fatalf("%s accept %s: format %s - expected %s", test.url, test.accept, format, test.format)
+		}
+	}
+}
+
+func TestWriteResults(t *testing.T) {
+	results := []*TestResult{
+		{Label: "v1", Test: TestBulkSeq, DriverVersion: "0.103.1", BatchCount: 10, BatchSize: 1000, NumRow: 10000, Seconds: 2, MBPerSecond: 1.5,
+			Stats: &RunStats{Seconds: []float64{2.5, 2}}},
+		{Label: "v1", Test: TestManyPar, DriverVersion: "0.103.1", BatchCount: 10, BatchSize: 1000, Workers: 4, Error: "failed"},
+	}
+
+	b := new(bytes.Buffer)
+
+	// csv
+	if err := WriteResults(b, formatCSV, results); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1][2] != "BulkSeq" || records[2][len(csvHeader)-1] != "failed" {
+		t.Fatalf("invalid csv %v", records)
+	}
+
+	// benchstat: one line per measured run, failed results are skipped
+	b.Reset()
+	if err := WriteResults(b, formatBenchstat, results); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "Benchmark") {
+			lines = append(lines, line)
+		}
+	}
+	expected := []string{
+		"BenchmarkBulkSeq/10x1000\t1\t2500000000 ns/op\t4000.00 rows/s\t1.20 MB/s",
+		"BenchmarkBulkSeq/10x1000\t1\t2000000000 ns/op\t5000.00 rows/s\t1.50 MB/s",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("benchstat lines\n%s\nexpected\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+	if !strings.Contains(b.String(), "driver: 0.103.1\n") {
+		t.Fatalf("driver configuration line missing\n%s", b)
+	}
+
+	// junit
+	b.Reset()
+	if err := WriteResults(b, formatJUnit, results); err != nil {
+		t.Fatal(err)
+	}
+	suites := &junitTestSuites{}
+	if err := xml.Unmarshal(b.Bytes(), suites); err != nil {
+		t.Fatal(err)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("number of test suites %d - expected 1", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	if suite.Tests != 2 || suite.Failures != 1 || len(suite.Cases) != 2 {
+		t.Fatalf("tests %d failures %d cases %d - expected 2 1 2", suite.Tests, suite.Failures, len(suite.Cases))
+	}
+	if tc := suite.Cases[1]; tc.ClassName != "hdbinsert.ManyPar" || tc.Name != "10x1000/workers=4" || tc.Failure == nil {
+		t.Fatalf("invalid test case %v", tc)
+	}
+
+	if err := WriteResults(b, "yaml", results); err == nil {
+		t.Fatal("invalid format error expected")
+	}
+}
@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// A RowGenerator generates test table rows.
+type RowGenerator interface {
+	// Row returns the fields of the row with index idx.
+	// All random values are taken from rnd, so that using a random number source with the
+	// same seed results in the same rows.
+	Row(rnd *rand.Rand, idx int) []interface{}
+}
+
+// A FieldGenerator generates the values of a test table column.
+type FieldGenerator interface {
+	// Value returns the column value of the row with index idx.
+	// All random values are taken from rnd.
+	Value(rnd *rand.Rand, idx int) interface{}
+}
+
+// batchRand returns the random number source used to generate the rows of batch i.
+// As each batch is using an own source, the generated data does not depend on the
+// order of execution (sequential or parallel tests). Seed and batch index are mixed
+// by a hash function, so that different seeds do not share (shifted) batch data.
+func batchRand(seed int64, i int) *rand.Rand {
+	return rand.New(rand.NewSource(int64(splitmix64(uint64(seed) ^ uint64(i)*0x9e3779b97f4a7c15))))
+}
+
+// splitmix64 is the finalizer of the SplitMix64 random number generator.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// sizer is implemented by generators of variable length values.
+type sizer interface {
+	size() int // average payload size in bytes
+}
+
+// Field generator names.
+const (
+	genSeq       = "seq"
+	genUniform   = "uniform"
+	genNormal    = "normal"
+	genConst     = "const"
+	genNull      = "null"
+	genString    = "string"
+	genBytes     = "bytes"
+	genTimestamp = "timestamp"
+	genNow       = "now"
+)
+
+// defTimestampStart is the default start time of the timestamp generator.
+var defTimestampStart = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// seqGenerator generates the row index.
+type seqGenerator struct{ c *column }
+
+func (g seqGenerator) Value(rnd *rand.Rand, idx int) interface{} { return g.c.convert(float64(idx)) }
+
+// uniformGenerator generates uniformly distributed values in [min,max).
+type uniformGenerator struct {
+	c        *column
+	min, max float64
+}
+
+func (g uniformGenerator) Value(rnd *rand.Rand, idx int) interface{} {
+	return g.c.convert(rnd.Float64()*(g.max-g.min) + g.min)
+}
+
+// normalGenerator generates normally distributed values.
+type normalGenerator struct {
+	c            *column
+	mean, stdDev float64
+}
+
+func (g normalGenerator) Value(rnd *rand.Rand, idx int) interface{} {
+	return g.c.convert(rnd.NormFloat64()*g.stdDev + g.mean)
+}
+
+// constGenerator generates a constant value.
+type constGenerator struct{ v interface{} }
+
+func (g constGenerator) Value(rnd *rand.Rand, idx int) interface{} { return g.v }
+
+// nullGenerator generates NULL values with probability ratio and
+// values of generator gen otherwise.
+type nullGenerator struct {
+	c     *column
+	ratio float64
+	gen   FieldGenerator
+}
+
+func (g nullGenerator) size() int {
+	size := g.c.size
+	if s, ok := g.gen.(sizer); ok {
+		size = s.size()
+	}
+	if size == 0 {
+		size = g.c.length
+	}
+	return int(float64(size) * (1 - g.ratio))
+}
+
+func (g nullGenerator) Value(rnd *rand.Rand, idx int) interface{} {
+	if rnd.Float64() < g.ratio {
+		return nil
+	}
+	return g.gen.Value(rnd, idx)
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// stringGenerator generates random alphanumeric strings of length n.
+type stringGenerator struct{ n int }
+
+func (g stringGenerator) size() int { return g.n }
+
+func (g stringGenerator) Value(rnd *rand.Rand, idx int) interface{} {
+	b := make([]byte, g.n)
+	for i := range b {
+		b[i] = letters[rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+// bytesGenerator generates random byte slices of length n.
+type bytesGenerator struct{ n int }
+
+func (g bytesGenerator) size() int { return g.n }
+
+func (g bytesGenerator) Value(rnd *rand.Rand, idx int) interface{} {
+	b := make([]byte, g.n)
+	rnd.Read(b)
+	return b
+}
+
+// timestampGenerator generates the timestamp start + idx * step.
+type timestampGenerator struct {
+	start time.Time
+	step  time.Duration
+}
+
+func (g timestampGenerator) Value(rnd *rand.Rand, idx int) interface{} {
+	return g.start.Add(time.Duration(idx) * g.step)
+}
+
+// nowGenerator generates the current time (not reproducible).
+type nowGenerator struct{}
+
+func (g nowGenerator) Value(rnd *rand.Rand, idx int) interface{} { return time.Now().UTC() }
+
+// parseConst parses a constant value of the column data type.
+func parseConst(c *column, s string) (interface{}, error) {
+	switch c.kind {
+	case kindString:
+		return s, nil
+	case kindBytes:
+		return []byte(s), nil
+	case kindBool:
+		return strconv.ParseBool(s)
+	case kindTime:
+		return time.Parse(time.RFC3339Nano, s)
+	default:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		return c.convert(v), nil
+	}
+}
+
+// newFieldGenerator returns the generator defined by spec for column c or
+// the default generator of the column data type if spec is empty.
+func newFieldGenerator(c *column, spec string) (FieldGenerator, error) {
+	if spec == "" {
+		switch c.kind {
+		case kindInt:
+			spec = genSeq
+		case kindString:
+			spec = fmt.Sprintf("%s(%d)", genString, c.length)
+		case kindBytes:
+			spec = fmt.Sprintf("%s(%d)", genBytes, c.length)
+		case kindTime:
+			spec = genTimestamp
+		default:
+			spec = genUniform + "(0,1)"
+		}
+	}
+
+	name, args, err := splitCall(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := func(err error) error { return fmt.Errorf("invalid generator %s: %s", spec, err) }
+
+	numeric := c.kind == kindInt || c.kind == kindFloat || c.kind == kindDecimal || c.kind == kindBool || c.kind == kindString
+
+	switch {
+	case name == genSeq && numeric && len(args) == 0:
+		return seqGenerator{c: c}, nil
+	case name == genUniform && numeric && len(args) == 2:
+		v, err := parseFloats(args)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return uniformGenerator{c: c, min: v[0], max: v[1]}, nil
+	case name == genNormal && numeric && len(args) == 2:
+		v, err := parseFloats(args)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return normalGenerator{c: c, mean: v[0], stdDev: v[1]}, nil
+	case name == genConst && len(args) == 1:
+		v, err := parseConst(c, args[0])
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return constGenerator{v: v}, nil
+	case name == genNull && len(args) == 2:
+		ratio, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		if ratio < 0 || ratio > 1 {
+			return nil, invalid(fmt.Errorf("ratio %f out of range [0,1]", ratio))
+		}
+		gen, err := newFieldGenerator(c, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return nullGenerator{c: c, ratio: ratio, gen: gen}, nil
+	case name == genString && c.kind == kindString && len(args) == 1:
+		v, err := parseInts(args)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		if v[0] < 0 {
+			return nil, invalid(fmt.Errorf("negative length %d", v[0]))
+		}
+		return stringGenerator{n: v[0]}, nil
+	case name == genBytes && c.kind == kindBytes && len(args) == 1:
+		v, err := parseInts(args)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		if v[0] < 0 {
+			return nil, invalid(fmt.Errorf("negative length %d", v[0]))
+		}
+		return bytesGenerator{n: v[0]}, nil
+	case name == genTimestamp && c.kind == kindTime && len(args) == 0:
+		return timestampGenerator{start: defTimestampStart, step: time.Second}, nil
+	case name == genTimestamp && c.kind == kindTime && len(args) == 2:
+		start, err := time.Parse(time.RFC3339Nano, args[0])
+		if err != nil {
+			return nil, invalid(err)
+		}
+		step, err := time.ParseDuration(args[1])
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return timestampGenerator{start: start, step: step}, nil
+	case name == genNow && c.kind == kindTime && len(args) == 0:
+		return nowGenerator{}, nil
+	}
+	return nil, fmt.Errorf("invalid generator %s for data type %s", spec, c.Type)
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// The histogram uses a HDR (high dynamic range) like bucket layout:
+// values are recorded in nanoseconds, each power of two range is divided
+// into histSubCount linear sub-buckets. So the relative error of a
+// recorded value is less than 1/histSubCount (< 1%).
+const (
+	histSubBits  = 7
+	histSubCount = 1 << histSubBits
+	histNumIdx   = histSubCount + (64-histSubBits)*histSubCount
+)
+
+// histIdx returns the bucket index of value v.
+func histIdx(v uint64) int {
+	if v < histSubCount {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1 - histSubBits // v in [2^(exp+histSubBits), 2^(exp+histSubBits+1))
+	sub := int(v>>uint(exp)) - histSubCount
+	return histSubCount + exp*histSubCount + sub
+}
+
+// histValue returns the highest value of bucket idx.
+func histValue(idx int) uint64 {
+	if idx < histSubCount {
+		return uint64(idx)
+	}
+	exp := (idx - histSubCount) / histSubCount
+	sub := (idx - histSubCount) % histSubCount
+	return (uint64(histSubCount+sub+1) << uint(exp)) - 1
+}
+
+// histogram records durations. Recording is safe for concurrent use.
+type histogram struct {
+	count  uint64 // accessed atomically
+	sum    uint64 // accessed atomically
+	max    uint64 // accessed atomically
+	counts [histNumIdx]uint64
+}
+
+func newHistogram() *histogram { return &histogram{} }
+
+// record adds duration d to the histogram.
+func (h *histogram) record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	v := uint64(d)
+	atomic.AddUint64(&h.counts[histIdx(v)], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, v)
+	for {
+		max := atomic.LoadUint64(&h.max)
+		if v <= max || atomic.CompareAndSwapUint64(&h.max, max, v) {
+			break
+		}
+	}
+}
+
+// quantiles returns the values at quantiles qs (0 <= q <= 1).
+// qs need to be in ascending order.
+func (h *histogram) quantiles(qs ...float64) []time.Duration {
+	r := make([]time.Duration, len(qs))
+	count := atomic.LoadUint64(&h.count)
+	if count == 0 {
+		return r
+	}
+	max := atomic.LoadUint64(&h.max)
+
+	var total uint64
+	j := 0
+	for idx := 0; idx < histNumIdx && j < len(qs); idx++ {
+		total += atomic.LoadUint64(&h.counts[idx])
+		for j < len(qs) && float64(total) >= math.Ceil(qs[j]*float64(count)) && total > 0 {
+			v := histValue(idx)
+			if v > max {
+				v = max
+			}
+			r[j] = time.Duration(v)
+			j++
+		}
+	}
+	for ; j < len(qs); j++ {
+		r[j] = time.Duration(max)
+	}
+	return r
+}
+
+// LatencyResult provides the statistics of the recorded statement execution latencies.
+type LatencyResult struct {
+	Count uint64
+	Mean  time.Duration
+	P50   time.Duration
+	P90   time.Duration
+	P99   time.Duration
+	P999  time.Duration
+	Max   time.Duration
+}
+
+// result returns the latency statistics of the histogram.
+func (h *histogram) result() *LatencyResult {
+	count := atomic.LoadUint64(&h.count)
+	if count == 0 {
+		return &LatencyResult{}
+	}
+	q := h.quantiles(0.5, 0.9, 0.99, 0.999)
+	return &LatencyResult{
+		Count: count,
+		Mean:  time.Duration(atomic.LoadUint64(&h.sum) / count),
+		P50:   q[0],
+		P90:   q[1],
+		P99:   q[2],
+		P999:  q[3],
+		Max:   time.Duration(atomic.LoadUint64(&h.max)),
+	}
+}
@@ -0,0 +1,48 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistIdx(t *testing.T) {
+	for _, v := range []uint64{0, 1, 127, 128, 129, 255, 256, 1000, 123456789, 1 << 40, 1<<63 + 12345} {
+		idx := histIdx(v)
+		if histValue(idx) < v {
+			t.Fatalf("value %d: bucket %d upper bound %d < value", v, idx, histValue(idx))
+		}
+		if idx > 0 && histValue(idx-1) >= v {
+			t.Fatalf("value %d: previous bucket %d upper bound %d >= value", v, idx-1, histValue(idx-1))
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := newHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	r := h.result()
+
+	if r.Count != 1000 {
+		t.Fatalf("count %d - expected %d", r.Count, 1000)
+	}
+	if r.Max != 1000*time.Microsecond {
+		t.Fatalf("max %s - expected %s", r.Max, 1000*time.Microsecond)
+	}
+
+	check := func(name string, v, expected time.Duration) {
+		// relative error less than 1%
+		if v < expected || float64(v-expected) > float64(expected)*0.01 {
+			t.Fatalf("%s %s - expected %s", name, v, expected)
+		}
+	}
+	check("p50", r.P50, 500*time.Microsecond)
+	check("p90", r.P90, 900*time.Microsecond)
+	check("p99", r.P99, 990*time.Microsecond)
+	check("p999", r.P999, 999*time.Microsecond)
+}
@@ -6,8 +6,10 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"html/template"
+	"io"
 	"net/http"
 	"runtime"
 
@@ -15,47 +17,108 @@ import (
 )
 
 // IndexHandler implements the http.Handler interface for the html index page.
+// The page is rendered per request, so that changes of the environment (e.g. the HANA version) are reflected.
 type IndexHandler struct {
-	b *bytes.Buffer
+	testHandler *TestHandler
+	dbHandler   *DBHandler
 }
 
 // NewIndexHandler returns a new IndexHandler instance.
 func NewIndexHandler(testHandler *TestHandler, dbHandler *DBHandler) (*IndexHandler, error) {
-	return (&IndexHandler{b: new(bytes.Buffer)}).init(testHandler, dbHandler)
+	h := &IndexHandler{testHandler: testHandler, dbHandler: dbHandler}
+	// check template
+	if err := indexTmpl.Execute(io.Discard, h.page(context.Background())); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// testMatrix is a table of test start links on the index page with one row per parameter and number of workers
+// and one column per test. Sequential tests ignore the number of workers, so that their start links are
+// displayed in the first workers row only.
+type testMatrix struct {
+	Groups  []testGroup
+	Cols    []testCol
+	Prms    [][]env.Prm
+	Workers []int
+}
+
+func (m *testMatrix) add(g testGroup) {
+	m.Groups = append(m.Groups, g)
+	m.Cols = append(m.Cols, g.Tests...)
 }
 
-func (h *IndexHandler) init(testHandler *TestHandler, dbHandler *DBHandler) (*IndexHandler, error) {
-	type page struct {
-		GOMAXPROCS    int
-		NumCPU        int
-		DriverVersion string
-		HDBVersion    string
-		Flags         []*flag.Flag
-		Prms          [][]env.Prm
-		Tests         []string
-		SchemaName    string
-		TableName     string
-		SchemaFuncs   []*dbFunc
-		TableFuncs    []*dbFunc
+// testMatrices returns the test matrix of the parameters command-line flag and the matrix of the
+// large object tests (lobParameters command-line flag), so that large objects are not inserted
+// with the batch sizes of the other tests.
+func testMatrices(groups []testGroup) []*testMatrix {
+	m := &testMatrix{Prms: env.Parameters().ToNumRecordList(), Workers: env.Workers().Workers}
+	lobM := &testMatrix{Prms: env.LobParameters().ToNumRecordList(), Workers: env.Workers().Workers}
+	for _, g := range groups {
+		if IsLobTest(g.Tests[0].Test) {
+			lobM.add(g)
+		} else {
+			m.add(g)
+		}
 	}
+	return []*testMatrix{m, lobM}
+}
+
+// indexPage is the data of the index page template.
+type indexPage struct {
+	GOMAXPROCS    int
+	NumCPU        int
+	DriverVersion string
+	HDBVersion    string
+	Flags         []*flag.Flag
+	TestGroups    []testGroup
+	Matrices      []*testMatrix
+	ResultPath    string
+	SchemaName    string
+	TableName     string
+	SchemaFuncs   []*dbFunc
+	TableFuncs    []*dbFunc
+	// custom test parameter defaults
+	BatchCount int
+	BatchSize  int
+	BufferSize int
+	Drop       bool
+	Separate   bool
+	Wait       int
+}
 
-	indexPage := page{
+// page returns the index page data querying the database with context ctx.
+func (h *IndexHandler) page(ctx context.Context) *indexPage {
+	return &indexPage{
 		GOMAXPROCS:    runtime.GOMAXPROCS(0),
 		NumCPU:        runtime.NumCPU(),
-		DriverVersion: dbHandler.DriverVersion(),
-		HDBVersion:    dbHandler.HDBVersion(),
+		DriverVersion: h.dbHandler.DriverVersion(),
+		HDBVersion:    h.dbHandler.hdbVersionContext(ctx),
 		Flags:         env.Flags(),
-		Prms:          env.Parameters().ToNumRecordList(),
-		Tests:         testHandler.tests(),
+		TestGroups:    h.testHandler.testGroups(),
+		Matrices:      testMatrices(h.testHandler.testGroups()),
+		ResultPath:    ResultPath,
 		SchemaName:    env.SchemaName(),
 		TableName:     env.TableName(),
-		SchemaFuncs:   dbHandler.schemaFuncs(),
-		TableFuncs:    dbHandler.tableFuncs(),
+		SchemaFuncs:   h.dbHandler.schemaFuncs(),
+		TableFuncs:    h.dbHandler.tableFuncs(),
+		BatchCount:    defBatchCount,
+		BatchSize:     defBatchSize,
+		BufferSize:    env.BufferSize(),
+		Drop:          env.Drop(),
+		Separate:      env.Separate(),
+		Wait:          env.Wait(),
 	}
-	return h, indexTmpl.Execute(h.b, indexPage)
 }
 
-func (h *IndexHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) { w.Write(h.b.Bytes()) }
+func (h *IndexHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b := new(bytes.Buffer)
+	if err := indexTmpl.Execute(b, h.page(r.Context())); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(b.Bytes()) // ignore error
+}
 
 var indexTmpl = template.Must(template.New("index").Parse(`
 {{define "root"}}
@@ -73,7 +136,225 @@ var indexTmpl = template.Must(template.New("index").Parse(`
 			border: 1px solid black;
 			border-collapse: collapse;
 		}
+
+		progress {
+			width: 30em;
+		}
+
+		.result {
+			font-size: small;
+		}
+
+		.error {
+			color: red;
+		}
 	</style>
+
+	<script>
+		var source = null;
+
+		// startTest executes the test of the link.
+		function startTest(link) {
+			return runTest(link.getAttribute("href"), link.parentNode);
+		}
+
+		// startCustomTest executes the test defined by the custom test parameter form.
+		function startCustomTest(form) {
+			var prms = ["batchcount", "batchsize", "buffersize", "workers", "wait"].map(function(name) {
+				return name + "=" + encodeURIComponent(form.elements[name].value);
+			});
+			prms.push("drop=" + form.elements["drop"].checked, "separate=" + form.elements["separate"].checked);
+			return runTest(form.elements["test"].value + "?" + prms.join("&"), document.getElementById("customResult"));
+		}
+
+		// runTest executes the test of url streaming the progress (Server-Sent Events)
+		// and displays the throughput in cell.
+		function runTest(url, cell) {
+			if (source != null) {
+				source.close();
+			}
+			var bar = document.getElementById("progress");
+			var status = document.getElementById("status");
+			var result = document.getElementById("result");
+			bar.value = 0;
+			status.textContent = "starting " + url;
+			result.textContent = "";
+
+			source = new EventSource(url + "&stream=1");
+			source.addEventListener("progress", function(e) {
+				var p = JSON.parse(e.data);
+				var finished = p.Workers.filter(function(w) { return w.Finished; }).length;
+				bar.value = p.Percent;
+				status.textContent = p.Test + ": " + p.NumRow + " rows - " + Math.round(p.RowsPerSecond) + " rows/s - " +
+					"workers finished " + finished + "/" + p.Workers.length + " - " + (p.Elapsed / 1e9).toFixed(1) + "s";
+			});
+			source.addEventListener("result", function(e) {
+				var r = JSON.parse(e.data);
+				source.close();
+				source = null;
+				bar.value = r.Error ? bar.value : 100;
+				status.textContent = r.Test + ": " + (r.Error ? "failed" : "finished");
+				result.textContent = JSON.stringify(r, null, 2);
+				showResult(cell, r);
+				loadChart();
+			});
+			source.onerror = function() {
+				source.close();
+				source = null;
+				status.textContent = "connection error";
+			};
+			return false;
+		}
+
+		// showResult displays the throughput of test result r in cell.
+		function showResult(cell, r) {
+			var span = cell.querySelector(".result");
+			if (span == null) {
+				span = document.createElement("span");
+				cell.appendChild(document.createElement("br"));
+				cell.appendChild(span);
+			}
+			span.className = r.Error ? "result error" : "result";
+			span.textContent = r.Error ? "error" : formatNumber(r.RowsPerSecond) + " rows/s";
+			span.title = r.Error ? r.Error : r.Seconds.toFixed(3) + "s - " + r.MBPerSecond.toFixed(2) + " MB/s";
+		}
+
+		function formatNumber(v) {
+			if (v >= 1e6) {
+				return (v / 1e6).toFixed(2) + "M";
+			}
+			if (v >= 1e3) {
+				return (v / 1e3).toFixed(1) + "k";
+			}
+			return v.toFixed(0);
+		}
+
+		function median(values) {
+			var s = values.slice().sort(function(a, b) { return a - b; });
+			var n = s.length;
+			return n % 2 == 1 ? s[(n - 1) / 2] : (s[n / 2 - 1] + s[n / 2]) / 2;
+		}
+
+		var resultPath = {{.ResultPath}};
+		var colors = ["#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7"];
+
+		// loadChart fetches the stored test results and renders the chart.
+		function loadChart() {
+			var status = document.getElementById("chartStatus");
+			var label = document.getElementById("chartLabel").value;
+			var url = resultPath + "?limit=" + encodeURIComponent(document.getElementById("chartLimit").value);
+			if (label != "") {
+				url += "&label=" + encodeURIComponent(label);
+			}
+			fetch(url).then(function(resp) {
+				return resp.json();
+			}).then(function(results) {
+				if (!Array.isArray(results)) {
+					throw new Error(results.Error);
+				}
+				status.textContent = results.length + " stored results";
+				renderChart(results);
+			}).catch(function(err) {
+				status.textContent = err.message;
+			});
+		}
+
+		function svgElement(tag, attrs, text) {
+			var e = document.createElementNS("http://www.w3.org/2000/svg", tag);
+			for (var name in attrs) {
+				e.setAttribute(name, attrs[name]);
+			}
+			if (text !== undefined) {
+				e.textContent = text;
+			}
+			return e;
+		}
+
+		// renderChart renders the median throughput of the successful results of the selected tests
+		// as bar chart grouped by batch shape (batchCount x batchSize and number of workers).
+		function renderChart(results) {
+			var tests = document.getElementById("chartGroup").value.split(",");
+			var metric = document.getElementById("chartMetric").value;
+
+			var shapes = [], data = {};
+			results.forEach(function(sr) {
+				var r = sr.Result;
+				if (r.Error || tests.indexOf(r.Test) < 0) {
+					return;
+				}
+				var shape = r.BatchCount + "x" + r.BatchSize + (r.Workers ? "/" + r.Workers : "");
+				if (!(shape in data)) {
+					data[shape] = {numRow: r.BatchCount * r.BatchSize, batchCount: r.BatchCount, workers: r.Workers || 0, values: {}};
+					shapes.push(shape);
+				}
+				(data[shape].values[r.Test] = data[shape].values[r.Test] || []).push(r[metric]);
+			});
+			shapes.sort(function(a, b) {
+				var da = data[a], db = data[b];
+				return da.numRow - db.numRow || da.batchCount - db.batchCount || da.workers - db.workers;
+			});
+
+			var max = 0;
+			shapes.forEach(function(shape) {
+				tests.forEach(function(test) {
+					var values = data[shape].values[test];
+					if (values) {
+						max = Math.max(max, median(values));
+					}
+				});
+			});
+
+			var chart = document.getElementById("chart");
+			chart.textContent = "";
+			if (shapes.length == 0) {
+				chart.textContent = "no results";
+				return;
+			}
+
+			var barWidth = 18, groupGap = 24, left = 70, top = 20, height = 240, bottom = 60;
+			var groupWidth = tests.length * barWidth + groupGap;
+			var width = left + shapes.length * groupWidth + 160;
+			var svg = svgElement("svg", {width: width, height: top + height + bottom});
+
+			// axes
+			svg.appendChild(svgElement("line", {x1: left, y1: top, x2: left, y2: top + height, stroke: "black"}));
+			svg.appendChild(svgElement("line", {x1: left, y1: top + height, x2: left + shapes.length * groupWidth, y2: top + height, stroke: "black"}));
+			for (var i = 0; i <= 4; i++) {
+				var y = top + height - i * height / 4;
+				svg.appendChild(svgElement("text", {x: left - 5, y: y + 4, "text-anchor": "end", "font-size": 11}, formatNumber(max * i / 4)));
+				svg.appendChild(svgElement("line", {x1: left - 3, y1: y, x2: left, y2: y, stroke: "black"}));
+			}
+
+			// bars
+			shapes.forEach(function(shape, i) {
+				var x0 = left + i * groupWidth + groupGap / 2;
+				tests.forEach(function(test, j) {
+					var values = data[shape].values[test];
+					if (!values || max == 0) {
+						return;
+					}
+					var v = median(values);
+					var h = v / max * height;
+					var bar = svgElement("rect", {x: x0 + j * barWidth, y: top + height - h, width: barWidth - 2, height: h, fill: colors[j % colors.length]});
+					bar.appendChild(svgElement("title", {}, test + " " + shape + ": " + formatNumber(v) + " (median of " + values.length + ")"));
+					svg.appendChild(bar);
+				});
+				svg.appendChild(svgElement("text", {x: x0 + tests.length * barWidth / 2, y: top + height + 15, "text-anchor": "middle", "font-size": 11}, shape));
+			});
+
+			// legend
+			var lx = left + shapes.length * groupWidth + 20;
+			tests.forEach(function(test, j) {
+				svg.appendChild(svgElement("rect", {x: lx, y: top + j * 18, width: 12, height: 12, fill: colors[j % colors.length]}));
+				svg.appendChild(svgElement("text", {x: lx + 16, y: top + j * 18 + 10, "font-size": 11}, test.substring(test.lastIndexOf("/") + 1)));
+			});
+			svg.appendChild(svgElement("text", {x: left, y: top + height + 40, "font-size": 11}, metric + " (median) by batchCount x batchSize[/workers]"));
+
+			chart.appendChild(svg);
+		}
+
+		window.addEventListener("load", loadChart);
+	</script>
 	
 	<body>
 	
@@ -103,38 +384,117 @@ var indexTmpl = template.Must(template.New("index").Parse(`
 			{{end}}
 		</table>
 
+		<br/>
+
+		<form onsubmit="return startCustomTest(this)">
+			<table border="1">
+				<tr><th colspan="100%">Custom test parameter</th></tr>
+				<tr>
+					<th>Test</th>
+					<th>BatchCount</th>
+					<th>BatchSize</th>
+					<th>BufferSize</th>
+					<th>Workers</th>
+					<th>Drop</th>
+					<th>Separate</th>
+					<th>Wait (s)</th>
+					<th></th>
+				</tr>
+				<tr>
+					<td>
+						<select name="test">
+							{{range .TestGroups}}
+							<optgroup label="{{.Name}}">
+								{{range .Tests}}<option value="{{.Test}}">{{.Header}}</option>{{end}}
+							</optgroup>
+							{{end}}
+						</select>
+					</td>
+					<td><input name="batchcount" type="number" min="1" value="{{.BatchCount}}" size="8"/></td>
+					<td><input name="batchsize" type="number" min="1" value="{{.BatchSize}}" size="8"/></td>
+					<td><input name="buffersize" type="number" min="1" value="{{.BufferSize}}" size="8"/></td>
+					<td><input name="workers" type="number" min="0" value="0" size="4" title="0: one worker per batch"/></td>
+					<td><input name="drop" type="checkbox" {{if .Drop}}checked{{end}}/></td>
+					<td><input name="separate" type="checkbox" {{if .Separate}}checked{{end}}/></td>
+					<td><input name="wait" type="number" min="0" value="{{.Wait}}" size="4"/></td>
+					<td><input type="submit" value="start"/> <span id="customResult"></span></td>
+				</tr>
+			</table>
+		</form>
+
+		<br/>
+
+		<table border="1">
+			<tr><th>Test progress</th></tr>
+			<tr><td><progress id="progress" max="100" value="0"></progress> <span id="status"></span></td></tr>
+			<tr><td><pre id="result"></pre></td></tr>
+		</table>
+
 		<br/>
 		
+		{{range .Matrices}}
 		<table border="1">
 			<thead>
 				<tr>
 					<th rowspan="2">BatchCount x BatchSize</th>
-					<th colspan="2">Sequential</th>
-					<th colspan="2">Parallel</th>
+					<th rowspan="2">Workers</th>
+					{{range .Groups}}
+					<th colspan="{{len .Tests}}">{{.Name}}</th>
+					{{end}}
 				</tr>
 				<tr>
-					<th>bulk</th>
-					<th>many</th>
-					<th>bulk</th>
-					<th>many</th>
+					{{range .Groups}}
+					{{range .Tests}}
+					<th>{{.Header}}</th>
+					{{end}}
+					{{end}}
 				</tr>
 			</thead>	
-			{{$Tests := .Tests}}
+			{{$Cols := .Cols}}
 			{{$Prms := .Prms}}
+			{{$Workers := .Workers}}
 			{{range $PrmSet := $Prms}}
 			</tbody>
 			{{range $Prm := $PrmSet}}
+			{{range $i, $Worker := $Workers}}
 			<tr>
 				<td>{{$Prm.BatchCount}} x {{$Prm.BatchSize}}</td>
-				{{range $Test := $Tests}}
-				<td>{{with $x := printf "%s?batchcount=%d&batchsize=%d" $Test $Prm.BatchCount $Prm.BatchSize }}<a href={{$x}}>start</a>{{end}}</td>
+				<td>{{if $Worker}}{{$Worker}}{{else}}per batch{{end}}</td>
+				{{range $Col := $Cols}}
+				<td>{{if or (not $Col.Seq) (eq $i 0)}}{{with $x := printf "%s?batchcount=%d&batchsize=%d&workers=%d" $Col.Test $Prm.BatchCount $Prm.BatchSize $Worker }}<a href={{$x}} onclick="return startTest(this)">start</a>{{end}}{{end}}</td>
 				{{end}}
 			</tr>
 			{{end}}
+			{{end}}
 			</tbody>
 			{{end}}
 		</table>
 
+		<br/>
+		{{end}}
+
+		<table border="1">
+			<tr><th>Result history</th></tr>
+			<tr>
+				<td>
+					Tests <select id="chartGroup" onchange="loadChart()">
+						{{range .TestGroups}}
+						<option value="{{range $i, $t := .Tests}}{{if $i}},{{end}}{{$t.Test}}{{end}}">{{.Name}}</option>
+						{{end}}
+					</select>
+					Metric <select id="chartMetric" onchange="loadChart()">
+						<option value="RowsPerSecond">rows/s</option>
+						<option value="MBPerSecond">MB/s</option>
+					</select>
+					Label <input id="chartLabel" size="12" onchange="loadChart()"/>
+					Results <input id="chartLimit" size="6" value="1000" onchange="loadChart()"/>
+					<button onclick="loadChart()">refresh</button>
+					<span id="chartStatus"></span>
+				</td>
+			</tr>
+			<tr><td id="chart"></td></tr>
+		</table>
+
 		<br/>
 			
 		<table border="1">
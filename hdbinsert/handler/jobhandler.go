@@ -0,0 +1,240 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job URL paths.
+const (
+	JobPath   = "/jobs/"
+	jobCancel = "cancel"
+)
+
+// Job status.
+const (
+	JobRunning  = "running"
+	JobFinished = "finished"
+	JobFailed   = "failed"
+	JobCanceled = "canceled"
+)
+
+// JobResult is the structure used to provide the JSON based job status response.
+type JobResult struct {
+	ID         string
+	Test       string
+	BatchCount int
+	BatchSize  int
+	Status     string
+	NumRow     int64
+	Seconds    float64
+	Elapsed    time.Duration
+	Result     *TestResult `json:",omitempty"`
+	Error      string
+}
+
+func (r *JobResult) String() string {
+	if r.Error != "" {
+		return fmt.Sprintf("job %s error: %s", r.ID, r.Error)
+	}
+	return fmt.Sprintf("job %s %s: %s - %s of %d rows in %f seconds (batchCount %d batchSize %d)", r.ID, r.Test, r.Status, testOp(r.Test), r.NumRow, r.Elapsed.Seconds(), r.BatchCount, r.BatchSize)
+}
+
+type runFunc func(ctx context.Context, m *monitor) *TestResult
+
+// job is an asynchronously executed test.
+type job struct {
+	id                    string
+	test                  string
+	batchCount, batchSize int
+	start                 time.Time
+	cancel                context.CancelFunc
+	m                     *monitor
+
+	mu       sync.Mutex
+	end      time.Time
+	canceled bool
+	done     bool
+	res      *TestResult
+}
+
+func (j *job) run(ctx context.Context, f runFunc) {
+	res := f(ctx, j.m)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.end = time.Now()
+	j.done = true
+	j.res = res
+	j.cancel() // release context resources
+}
+
+func (j *job) stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.done {
+		return
+	}
+	j.canceled = true
+	j.cancel()
+}
+
+func (j *job) result() *JobResult {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	r := &JobResult{ID: j.id, Test: j.test, BatchCount: j.batchCount, BatchSize: j.batchSize, NumRow: j.m.rows()}
+
+	switch {
+	case !j.done:
+		r.Status = JobRunning
+		r.Elapsed = time.Since(j.start)
+	case j.canceled:
+		r.Status = JobCanceled
+		r.Elapsed = j.end.Sub(j.start)
+	case j.res.Error != "":
+		r.Status = JobFailed
+		r.Elapsed = j.end.Sub(j.start)
+	default:
+		r.Status = JobFinished
+		r.Elapsed = j.end.Sub(j.start)
+	}
+	r.Seconds = r.Elapsed.Seconds()
+	r.Result = j.res
+	return r
+}
+
+// expired reports whether the job is done for longer than ttl.
+func (j *job) expired(now time.Time, ttl time.Duration) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.done && now.Sub(j.end) > ttl
+}
+
+// jobTTL is the time a done job is kept in the job registry.
+const jobTTL = time.Hour
+
+// jobs is the registry of asynchronously executed tests. Done jobs are removed from the registry after ttl.
+type jobs struct {
+	mu     sync.RWMutex
+	ttl    time.Duration
+	lastID int
+	jobs   map[string]*job
+}
+
+func newJobs() *jobs { return &jobs{ttl: jobTTL, jobs: make(map[string]*job)} }
+
+// evict removes the expired jobs from the registry. The caller must hold the registry lock.
+func (js *jobs) evict(now time.Time) {
+	for id, j := range js.jobs {
+		if j.expired(now, js.ttl) {
+			delete(js.jobs, id)
+		}
+	}
+}
+
+// start executes f in a separate goroutine and returns the job.
+// The job context is derived from ctx.
+func (js *jobs) start(ctx context.Context, test string, batchCount, batchSize int, f runFunc) *job {
+	js.mu.Lock()
+	js.evict(time.Now())
+	js.lastID++
+	id := strconv.Itoa(js.lastID)
+	ctx, cancel := context.WithCancel(ctx)
+	j := &job{id: id, test: test, batchCount: batchCount, batchSize: batchSize, start: time.Now(), cancel: cancel, m: newMonitor()}
+	js.jobs[id] = j
+	js.mu.Unlock()
+
+	go j.run(ctx, f)
+	return j
+}
+
+func (js *jobs) get(id string) (*job, bool) {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+	j, ok := js.jobs[id]
+	return j, ok
+}
+
+// list returns all jobs ordered by id.
+func (js *jobs) list() []*job {
+	js.mu.RLock()
+	defer js.mu.RUnlock()
+	l := make([]*job, 0, len(js.jobs))
+	for _, j := range js.jobs {
+		l = append(l, j)
+	}
+	sort.Slice(l, func(i, k int) bool {
+		ii, _ := strconv.Atoi(l[i].id)
+		ik, _ := strconv.Atoi(l[k].id)
+		return ii < ik
+	})
+	return l
+}
+
+// JobHandler implements the http.Handler interface for asynchronous test jobs.
+type JobHandler struct {
+	log  logFunc
+	jobs *jobs
+}
+
+// NewJobHandler returns a new JobHandler instance.
+func NewJobHandler(log logFunc, testHandler *TestHandler) (*JobHandler, error) {
+	return &JobHandler{log: log, jobs: testHandler.jobs}, nil
+}
+
+// ServeHTTP handles the URL paths
+//
+//	/jobs/           (list all jobs)
+//	/jobs/<id>       (job status)
+//	/jobs/<id>/cancel (cancel job)
+func (h *JobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e := json.NewEncoder(w)
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, JobPath), "/")
+	if path == "" {
+		jobs := h.jobs.list()
+		results := make([]*JobResult, len(jobs))
+		for i, j := range jobs {
+			results[i] = j.result()
+		}
+		e.Encode(results) // ignore error
+		return
+	}
+
+	parts := strings.Split(path, "/")
+	id := parts[0]
+
+	var result *JobResult
+	defer func() {
+		h.log("%s", result)
+		e.Encode(result) // ignore error
+	}()
+
+	j, ok := h.jobs.get(id)
+	if !ok {
+		result = &JobResult{ID: id, Error: fmt.Sprintf("Invalid job %s", id)}
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 2 && parts[1] == jobCancel:
+		j.stop()
+	default:
+		result = &JobResult{ID: id, Error: fmt.Sprintf("Invalid job command %s", path)}
+		return
+	}
+	result = j.result()
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func waitJob(t *testing.T, j *job) *JobResult {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if r := j.result(); r.Status != JobRunning {
+			return r
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s still running", j.id)
+	return nil
+}
+
+func TestJobs(t *testing.T) {
+	js := newJobs()
+
+	finished := js.start(context.Background(), "/test/BulkSeq", 1, 10, func(ctx context.Context, m *monitor) *TestResult {
+		m.exec(time.Millisecond, 10)
+		return &TestResult{NumRow: 10}
+	})
+	failed := js.start(context.Background(), "/test/ManySeq", 1, 10, func(ctx context.Context, m *monitor) *TestResult {
+		return &TestResult{Error: "test error"}
+	})
+	running := js.start(context.Background(), "/test/BulkPar", 1, 10, func(ctx context.Context, m *monitor) *TestResult {
+		<-ctx.Done()
+		return &TestResult{Error: ctx.Err().Error()}
+	})
+
+	for _, test := range []struct {
+		job    *job
+		status string
+		numRow int64
+	}{
+		{finished, JobFinished, 10},
+		{failed, JobFailed, 0},
+	} {
+		r := waitJob(t, test.job)
+		if r.Status != test.status || r.NumRow != test.numRow || r.Result == nil {
+			t.Fatalf("job %s: status %s rows %d - expected %s %d", r.ID, r.Status, r.NumRow, test.status, test.numRow)
+		}
+	}
+
+	if r := running.result(); r.Status != JobRunning || r.Result != nil {
+		t.Fatalf("job %s: status %s - expected %s", r.ID, r.Status, JobRunning)
+	}
+	running.stop()
+	if r := waitJob(t, running); r.Status != JobCanceled {
+		t.Fatalf("job %s: status %s - expected %s", r.ID, r.Status, JobCanceled)
+	}
+	finished.stop() // no-op for done jobs
+	if r := finished.result(); r.Status != JobFinished {
+		t.Fatalf("job %s: status %s - expected %s", r.ID, r.Status, JobFinished)
+	}
+
+	l := js.list()
+	if len(l) != 3 || l[0] != finished || l[1] != failed || l[2] != running {
+		t.Fatalf("invalid job list %v", l)
+	}
+
+	// evict done jobs after ttl
+	js.ttl = 0
+	time.Sleep(time.Millisecond)
+	js.start(context.Background(), "/test/BulkSeq", 1, 10, func(ctx context.Context, m *monitor) *TestResult {
+		<-ctx.Done()
+		return &TestResult{Error: ctx.Err().Error()}
+	}).stop()
+	if l := js.list(); len(l) != 1 || l[0].id != "4" {
+		t.Fatalf("invalid job list after eviction %v", l)
+	}
+	if _, ok := js.get(finished.id); ok {
+		t.Fatalf("job %s not evicted", finished.id)
+	}
+}
+
+func TestJobHandler(t *testing.T) {
+	h := &JobHandler{log: t.Logf, jobs: newJobs()}
+	j := h.jobs.start(context.Background(), "/test/BulkSeq", 1, 10, func(ctx context.Context, m *monitor) *TestResult {
+		<-ctx.Done()
+		return &TestResult{Error: ctx.Err().Error()}
+	})
+
+	serve := func(path string, v interface{}) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if err := json.NewDecoder(w.Body).Decode(v); err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+	}
+
+	var results []*JobResult
+	serve(JobPath, &results)
+	if len(results) != 1 || results[0].ID != j.id || results[0].Status != JobRunning {
+		t.Fatalf("invalid job list %v", results)
+	}
+
+	for _, test := range []struct {
+		path   string
+		status string
+		error  bool
+	}{
+		{JobPath + j.id, JobRunning, false},
+		{JobPath + j.id + "/cancel", JobCanceled, false},
+		{JobPath + j.id + "/unknown", "", true},
+		{JobPath + "42", "", true},
+	} {
+		r := &JobResult{}
+		serve(test.path, r)
+		if (r.Error != "") != test.error {
+			t.Fatalf("%s: error %q", test.path, r.Error)
+		}
+		if test.status == JobCanceled {
+			r = waitJob(t, j)
+		}
+		if r.Status != test.status {
+			t.Fatalf("%s: status %s - expected %s", test.path, r.Status, test.status)
+		}
+	}
+}
+
+func TestJobResultString(t *testing.T) {
+	r := &JobResult{ID: "1", Test: TestSelectSeq, Status: JobFinished, NumRow: 100, Elapsed: time.Second, BatchCount: 1, BatchSize: 100}
+	if s, expected := r.String(), "job 1 /test/SelectSeq: finished - select of 100 rows in 1.000000 seconds (batchCount 1 batchSize 100)"; s != expected {
+		t.Fatalf("%s - expected %s", s, expected)
+	}
+}
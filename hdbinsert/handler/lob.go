@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// Large object data types.
+const (
+	lobTypeBlob  = "BLOB"
+	lobTypeClob  = "CLOB"
+	lobTypeNClob = "NCLOB"
+)
+
+func checkLobType(lobType string) (string, error) {
+	lobType = strings.ToUpper(lobType)
+	switch lobType {
+	case lobTypeBlob, lobTypeClob, lobTypeNClob:
+		return lobType, nil
+	default:
+		return "", fmt.Errorf("invalid large object type %s", lobType)
+	}
+}
+
+// IsLobTest returns true if test is a large object test.
+func IsLobTest(test string) bool { return test == TestLobSeq || test == TestLobPar }
+
+// maxLobPattern is the maximum size of the data pattern the large object content is built of.
+const maxLobPattern = 1 << 16
+
+// lobReader is an io.Reader providing the content of a large object by
+// repeating a data pattern starting at offset.
+type lobReader struct {
+	pattern []byte
+	offset  int
+	n       int // remaining bytes
+}
+
+func (r *lobReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	read := 0
+	for read < len(p) {
+		c := copy(p[read:], r.pattern[r.offset:])
+		read += c
+		r.offset = (r.offset + c) % len(r.pattern)
+	}
+	r.n -= read
+	return read, nil
+}
+
+// lobTable returns the name of the large object test table.
+func (h *TestHandler) lobTable(i int, separate bool) string {
+	if separate {
+		return fmt.Sprintf("%s_LOB_%d", h.tableName, i)
+	}
+	return h.tableName + "_LOB"
+}
+
+func (h *TestHandler) lobColumns() string { return "ID INTEGER, DATA " + h.lobType }
+
+// lobPattern returns the data pattern the large object content of batch i is built of.
+// For character large objects the pattern consists of alphanumeric characters only.
+func (h *TestHandler) lobPattern(prm *testPrm, i int) []byte {
+	size := prm.lobSize
+	if size > maxLobPattern {
+		size = maxLobPattern
+	}
+	if size == 0 {
+		size = 1
+	}
+	rnd := batchRand(prm.seed, i)
+	b := make([]byte, size)
+	if h.lobType == lobTypeBlob {
+		rnd.Read(b)
+	} else {
+		for j := range b {
+			b[j] = letters[rnd.Intn(len(letters))]
+		}
+	}
+	return b
+}
+
+// insertLobs inserts the batchSize large objects of batch i into table within one transaction,
+// as lob streaming is not permitted in auto commit mode. The executions are recorded after a
+// successful commit only, as otherwise all rows of the batch are rolled back.
+func (h *TestHandler) insertLobs(ctx context.Context, m *monitor, conn *sql.Conn, prm *testPrm, tableName string, i int) (time.Duration, error) {
+	pattern := h.lobPattern(prm, i)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // ignored after commit
+
+	stmt, err := tx.PrepareContext(ctx, getInsertQuery(h.schemaName, tableName, 2))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var d time.Duration
+	execs := make([]time.Duration, prm.batchSize)
+
+	for j := 0; j < prm.batchSize; j++ {
+		idx := i*prm.batchSize + j
+		lob := new(driver.Lob).SetReader(&lobReader{pattern: pattern, offset: idx % len(pattern), n: prm.lobSize})
+		t := time.Now()
+		if _, err := stmt.ExecContext(ctx, idx, lob); err != nil {
+			return d, err
+		}
+		execs[j] = time.Since(t)
+		d += execs[j]
+	}
+
+	t := time.Now()
+	if err := tx.Commit(); err != nil {
+		return d, err
+	}
+	for _, e := range execs {
+		m.exec(e, 1)
+	}
+	return d + time.Since(t), nil
+}
+
+func (h *TestHandler) lobSeq(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+	m.rowSize = 4 + prm.lobSize
+
+	tableName := h.lobTable(0, false)
+	if err := ensureTable(ctx, db, h.schemaName, tableName, h.lobColumns(), prm.drop); err != nil {
+		return 0, err
+	}
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	defer m.startWorker()()
+	m.startWorkers(prm.batchCount)
+	defer m.workerFinished(0)
+
+	var d time.Duration
+
+	for i := 0; i < prm.batchCount; i++ {
+		e, err := h.insertLobs(ctx, m, conn, prm, tableName, i)
+		d += e
+		if err != nil {
+			return d, err
+		}
+		m.batchDone(0)
+	}
+	return d, nil
+}
+
+func (h *TestHandler) lobPar(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+	m.rowSize = 4 + prm.lobSize
+
+	if !prm.separate {
+		if err := ensureTable(ctx, db, h.schemaName, h.lobTable(0, false), h.lobColumns(), prm.drop); err != nil {
+			return 0, err
+		}
+	}
+
+	tasks, err := newTasks(ctx, db, prm)
+	if err != nil {
+		return 0, err
+	}
+	defer closeTasks(tasks)
+
+	for i := 0; i < prm.batchCount; i++ {
+		tableName := h.lobTable(i, prm.separate)
+		if prm.separate {
+			if err := ensureTable(ctx, db, h.schemaName, tableName, h.lobColumns(), prm.drop); err != nil {
+				return 0, err
+			}
+		}
+		// statements are prepared per transaction (see insertLobs)
+		t := tasks[i%len(tasks)]
+		t.batches = append(t.batches, &taskBatch{table: tableName, offset: i * prm.batchSize})
+	}
+
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
+	}
+
+	d := runTasks(m, tasks, func(worker int, t *task, b *taskBatch) {
+		if _, err := h.insertLobs(ctx, m, t.conn, prm, b.table, b.offset/prm.batchSize); err != nil {
+			m.failBatch(worker, b.table, b.offset, prm.batchSize, err) // transaction rolled back
+		}
+	})
+
+	return d, m.err()
+}
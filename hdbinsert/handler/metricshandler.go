@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MetricsPath is the URL path of the metrics endpoint.
+const MetricsPath = "/metrics"
+
+// metricsPrefix is the name prefix of all exposed metrics.
+const metricsPrefix = "hdbinsert_"
+
+// execBuckets are the upper bounds in seconds of the statement execution latency histogram buckets.
+var execBuckets = []float64{0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// testMetrics holds the metrics of one test type.
+// All fields are accessed atomically.
+type testMetrics struct {
+	numRow        uint64
+	numRun        uint64
+	numError      uint64
+	activeWorkers int64
+	execCount     uint64
+	execSum       uint64 // nanoseconds
+	execBuckets   []uint64
+}
+
+func newTestMetrics() *testMetrics {
+	return &testMetrics{execBuckets: make([]uint64, len(execBuckets))}
+}
+
+func (m *testMetrics) exec(d time.Duration, numRow int) {
+	atomic.AddUint64(&m.numRow, uint64(numRow))
+	atomic.AddUint64(&m.execCount, 1)
+	atomic.AddUint64(&m.execSum, uint64(d))
+	secs := d.Seconds()
+	for i, b := range execBuckets {
+		if secs <= b {
+			atomic.AddUint64(&m.execBuckets[i], 1)
+			break
+		}
+	}
+}
+
+func (m *testMetrics) addRows(numRow int) { atomic.AddUint64(&m.numRow, uint64(numRow)) }
+
+func (m *testMetrics) addWorker(n int64) { atomic.AddInt64(&m.activeWorkers, n) }
+
+// dbMetrics holds the accumulated sql.DB statistics of closed databases.
+type dbMetrics struct {
+	waitCount         int64
+	waitDuration      time.Duration
+	maxIdleClosed     int64
+	maxIdleTimeClosed int64
+	maxLifetimeClosed int64
+}
+
+func (m *dbMetrics) add(stats sql.DBStats) {
+	m.waitCount += stats.WaitCount
+	m.waitDuration += stats.WaitDuration
+	m.maxIdleClosed += stats.MaxIdleClosed
+	m.maxIdleTimeClosed += stats.MaxIdleTimeClosed
+	m.maxLifetimeClosed += stats.MaxLifetimeClosed
+}
+
+// metrics collects the metrics of all tests.
+type metrics struct {
+	mu     sync.RWMutex
+	tests  map[string]*testMetrics
+	dbs    map[*sql.DB]string // open test databases by test name
+	closed map[string]*dbMetrics
+}
+
+func newMetrics() *metrics {
+	return &metrics{tests: make(map[string]*testMetrics), dbs: make(map[*sql.DB]string), closed: make(map[string]*dbMetrics)}
+}
+
+// testName returns the metrics label of a test URL path.
+func testName(test string) string { return path.Base(test) }
+
+// test returns the metrics of test.
+func (m *metrics) test(test string) *testMetrics {
+	name := testName(test)
+
+	m.mu.RLock()
+	tm, ok := m.tests[name]
+	m.mu.RUnlock()
+	if ok {
+		return tm
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if tm, ok := m.tests[name]; ok {
+		return tm
+	}
+	tm = newTestMetrics()
+	m.tests[name] = tm
+	return tm
+}
+
+// addDB registers an open test database.
+func (m *metrics) addDB(test string, db *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dbs[db] = testName(test)
+}
+
+// removeDB unregisters a test database before closing it.
+func (m *metrics) removeDB(db *sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name, ok := m.dbs[db]
+	if !ok {
+		return
+	}
+	delete(m.dbs, db)
+	dm, ok := m.closed[name]
+	if !ok {
+		dm = &dbMetrics{}
+		m.closed[name] = dm
+	}
+	dm.add(db.Stats())
+}
+
+type metricsWriter struct {
+	w *bufio.Writer
+}
+
+func (w *metricsWriter) header(name, typ, help string) {
+	fmt.Fprintf(w.w, "# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, typ)
+}
+
+func (w *metricsWriter) value(name, labels string, v float64) {
+	fmt.Fprintf(w.w, "%s%s{%s} %s\n", metricsPrefix, name, labels, strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+// labelValueReplacer escapes label values as defined by the Prometheus text exposition format.
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func testLabel(name string) string { return `test="` + labelValueReplacer.Replace(name) + `"` }
+
+// write writes the metrics in the Prometheus text exposition format.
+func (m *metrics) write(w *metricsWriter) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.tests))
+	for name := range m.tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counter := func(name, help string, f func(tm *testMetrics) float64) {
+		w.header(name, "counter", help)
+		for _, test := range names {
+			w.value(name, testLabel(test), f(m.tests[test]))
+		}
+	}
+
+	counter("rows_processed_total", "Number of processed (inserted, selected, upserted, updated or deleted) rows.", func(tm *testMetrics) float64 { return float64(atomic.LoadUint64(&tm.numRow)) })
+	counter("test_runs_total", "Number of test runs.", func(tm *testMetrics) float64 { return float64(atomic.LoadUint64(&tm.numRun)) })
+	counter("test_errors_total", "Number of test runs finished with an error.", func(tm *testMetrics) float64 { return float64(atomic.LoadUint64(&tm.numError)) })
+
+	w.header("active_workers", "gauge", "Number of active test workers.")
+	for _, test := range names {
+		w.value("active_workers", testLabel(test), float64(atomic.LoadInt64(&m.tests[test].activeWorkers)))
+	}
+
+	w.header("exec_duration_seconds", "histogram", "Latency of statement executions.")
+	for _, test := range names {
+		tm := m.tests[test]
+		label := testLabel(test)
+		var cum uint64
+		for i, b := range execBuckets {
+			cum += atomic.LoadUint64(&tm.execBuckets[i])
+			w.value("exec_duration_seconds_bucket", fmt.Sprintf("%s,le=%q", label, strconv.FormatFloat(b, 'g', -1, 64)), float64(cum))
+		}
+		count := atomic.LoadUint64(&tm.execCount)
+		w.value("exec_duration_seconds_bucket", fmt.Sprintf("%s,le=\"+Inf\"", label), float64(count))
+		w.value("exec_duration_seconds_sum", label, time.Duration(atomic.LoadUint64(&tm.execSum)).Seconds())
+		w.value("exec_duration_seconds_count", label, float64(count))
+	}
+
+	// Database statistics of open and closed test databases.
+	open := make(map[string]*sql.DBStats)
+	dbNames := make([]string, 0)
+	for name := range m.closed {
+		dbNames = append(dbNames, name)
+	}
+	for db, name := range m.dbs {
+		stats, ok := open[name]
+		if !ok {
+			stats = &sql.DBStats{}
+			open[name] = stats
+			if _, ok := m.closed[name]; !ok {
+				dbNames = append(dbNames, name)
+			}
+		}
+		s := db.Stats()
+		stats.OpenConnections += s.OpenConnections
+		stats.InUse += s.InUse
+		stats.Idle += s.Idle
+		stats.WaitCount += s.WaitCount
+		stats.WaitDuration += s.WaitDuration
+		stats.MaxIdleClosed += s.MaxIdleClosed
+		stats.MaxIdleTimeClosed += s.MaxIdleTimeClosed
+		stats.MaxLifetimeClosed += s.MaxLifetimeClosed
+	}
+	sort.Strings(dbNames)
+
+	dbStats := func(name string) (*sql.DBStats, *dbMetrics) {
+		stats, ok := open[name]
+		if !ok {
+			stats = &sql.DBStats{}
+		}
+		closed, ok := m.closed[name]
+		if !ok {
+			closed = &dbMetrics{}
+		}
+		return stats, closed
+	}
+
+	dbMetric := func(name, typ, help string, f func(stats *sql.DBStats, closed *dbMetrics) float64) {
+		w.header(name, typ, help)
+		for _, test := range dbNames {
+			w.value(name, testLabel(test), f(dbStats(test)))
+		}
+	}
+
+	dbMetric("db_open_connections", "gauge", "Number of established connections of open test databases.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.OpenConnections) })
+	dbMetric("db_in_use_connections", "gauge", "Number of connections in use of open test databases.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.InUse) })
+	dbMetric("db_idle_connections", "gauge", "Number of idle connections of open test databases.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.Idle) })
+	dbMetric("db_wait_count_total", "counter", "Total number of connections waited for.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.WaitCount + c.waitCount) })
+	dbMetric("db_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.", func(s *sql.DBStats, c *dbMetrics) float64 { return (s.WaitDuration + c.waitDuration).Seconds() })
+	dbMetric("db_max_idle_closed_total", "counter", "Total number of connections closed due to SetMaxIdleConns.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.MaxIdleClosed + c.maxIdleClosed) })
+	dbMetric("db_max_idle_time_closed_total", "counter", "Total number of connections closed due to SetConnMaxIdleTime.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.MaxIdleTimeClosed + c.maxIdleTimeClosed) })
+	dbMetric("db_max_lifetime_closed_total", "counter", "Total number of connections closed due to SetConnMaxLifetime.", func(s *sql.DBStats, c *dbMetrics) float64 { return float64(s.MaxLifetimeClosed + c.maxLifetimeClosed) })
+}
+
+// MetricsHandler implements the http.Handler interface for the Prometheus metrics endpoint.
+type MetricsHandler struct {
+	metrics *metrics
+}
+
+// NewMetricsHandler returns a new MetricsHandler instance.
+func NewMetricsHandler(testHandler *TestHandler) (*MetricsHandler, error) {
+	return &MetricsHandler{metrics: testHandler.metrics}, nil
+}
+
+func (h *MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	mw := &metricsWriter{w: bufio.NewWriter(w)}
+	h.metrics.write(mw)
+	mw.w.Flush() // ignore error
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	m := newMetrics()
+
+	tm := m.test("/test/BulkSeq")
+	tm.exec(200*time.Microsecond, 1000)
+	tm.exec(3*time.Millisecond, 1000)
+	tm.exec(20*time.Second, 0) // +Inf bucket only
+	tm.numRun, tm.numError, tm.activeWorkers = 2, 1, 4
+
+	m.test(`/test/a"b\c` + "\nd") // label escaping
+	m.closed["BulkSeq"] = &dbMetrics{waitCount: 3, waitDuration: 1500 * time.Millisecond, maxIdleClosed: 1}
+
+	w := httptest.NewRecorder()
+	(&MetricsHandler{metrics: m}).ServeHTTP(w, httptest.NewRequest("GET", MetricsPath, nil))
+	if got := w.Body.String(); got != metricsGolden {
+		t.Fatalf("invalid metrics\ngot:\n%s\nexpected:\n%s", got, metricsGolden)
+	}
+}
+
+const metricsGolden = `# HELP hdbinsert_rows_processed_total Number of processed (inserted, selected, upserted, updated or deleted) rows.
+# TYPE hdbinsert_rows_processed_total counter
+hdbinsert_rows_processed_total{test="BulkSeq"} 2000
+hdbinsert_rows_processed_total{test="a\"b\\c\nd"} 0
+# HELP hdbinsert_test_runs_total Number of test runs.
+# TYPE hdbinsert_test_runs_total counter
+hdbinsert_test_runs_total{test="BulkSeq"} 2
+hdbinsert_test_runs_total{test="a\"b\\c\nd"} 0
+# HELP hdbinsert_test_errors_total Number of test runs finished with an error.
+# TYPE hdbinsert_test_errors_total counter
+hdbinsert_test_errors_total{test="BulkSeq"} 1
+hdbinsert_test_errors_total{test="a\"b\\c\nd"} 0
+# HELP hdbinsert_active_workers Number of active test workers.
+# TYPE hdbinsert_active_workers gauge
+hdbinsert_active_workers{test="BulkSeq"} 4
+hdbinsert_active_workers{test="a\"b\\c\nd"} 0
+# HELP hdbinsert_exec_duration_seconds Latency of statement executions.
+# TYPE hdbinsert_exec_duration_seconds histogram
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.0001"} 0
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.00025"} 1
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.0005"} 1
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.001"} 1
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.0025"} 1
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.005"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.01"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.025"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.05"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.1"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.25"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="0.5"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="1"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="2.5"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="5"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="10"} 2
+hdbinsert_exec_duration_seconds_bucket{test="BulkSeq",le="+Inf"} 3
+hdbinsert_exec_duration_seconds_sum{test="BulkSeq"} 20.0032
+hdbinsert_exec_duration_seconds_count{test="BulkSeq"} 3
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.0001"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.00025"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.0005"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.001"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.0025"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.005"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.01"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.025"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.05"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.1"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.25"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="0.5"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="1"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="2.5"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="5"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="10"} 0
+hdbinsert_exec_duration_seconds_bucket{test="a\"b\\c\nd",le="+Inf"} 0
+hdbinsert_exec_duration_seconds_sum{test="a\"b\\c\nd"} 0
+hdbinsert_exec_duration_seconds_count{test="a\"b\\c\nd"} 0
+# HELP hdbinsert_db_open_connections Number of established connections of open test databases.
+# TYPE hdbinsert_db_open_connections gauge
+hdbinsert_db_open_connections{test="BulkSeq"} 0
+# HELP hdbinsert_db_in_use_connections Number of connections in use of open test databases.
+# TYPE hdbinsert_db_in_use_connections gauge
+hdbinsert_db_in_use_connections{test="BulkSeq"} 0
+# HELP hdbinsert_db_idle_connections Number of idle connections of open test databases.
+# TYPE hdbinsert_db_idle_connections gauge
+hdbinsert_db_idle_connections{test="BulkSeq"} 0
+# HELP hdbinsert_db_wait_count_total Total number of connections waited for.
+# TYPE hdbinsert_db_wait_count_total counter
+hdbinsert_db_wait_count_total{test="BulkSeq"} 3
+# HELP hdbinsert_db_wait_duration_seconds_total Total time blocked waiting for a new connection.
+# TYPE hdbinsert_db_wait_duration_seconds_total counter
+hdbinsert_db_wait_duration_seconds_total{test="BulkSeq"} 1.5
+# HELP hdbinsert_db_max_idle_closed_total Total number of connections closed due to SetMaxIdleConns.
+# TYPE hdbinsert_db_max_idle_closed_total counter
+hdbinsert_db_max_idle_closed_total{test="BulkSeq"} 1
+# HELP hdbinsert_db_max_idle_time_closed_total Total number of connections closed due to SetConnMaxIdleTime.
+# TYPE hdbinsert_db_max_idle_time_closed_total counter
+hdbinsert_db_max_idle_time_closed_total{test="BulkSeq"} 0
+# HELP hdbinsert_db_max_lifetime_closed_total Total number of connections closed due to SetConnMaxLifetime.
+# TYPE hdbinsert_db_max_lifetime_closed_total counter
+hdbinsert_db_max_lifetime_closed_total{test="BulkSeq"} 0
+`
@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// Plan is a test plan consisting of named scenarios executed in order.
+type Plan struct {
+	Name      string      `json:"name"`
+	Label     string      `json:"label"` // label of the stored test results - label command-line flag if empty
+	Seed      int64       `json:"seed"`  // seed of the test data - seed command-line flag if zero
+	Scenarios []*Scenario `json:"scenarios"`
+}
+
+// Scenario is a named test execution of a test plan. Zero values are replaced by the command-line flags
+// (batchCount and batchSize: test defaults, bulkSize: batchSize, workers: one worker per batch, repeat: 1).
+// The setup and teardown SQL statements are executed before and after the test. The placeholders ${schema}
+// and ${table} are replaced by the schema name and table name command-line flags.
+type Scenario struct {
+	Name       string   `json:"name"`
+	Test       string   `json:"test"` // test type (e.g. BulkSeq)
+	BatchCount int      `json:"batchCount"`
+	BatchSize  int      `json:"batchSize"`
+	Workers    int      `json:"workers"`
+	BufferSize int      `json:"bufferSize"`
+	BulkSize   int      `json:"bulkSize"`
+	FetchSize  int      `json:"fetchSize"`
+	Drop       *bool    `json:"drop"`
+	Separate   *bool    `json:"separate"`
+	Wait       *int     `json:"wait"`    // seconds
+	Timeout    int      `json:"timeout"` // seconds - no timeout if zero
+	Repeat     int      `json:"repeat"`
+	Warmup     int      `json:"warmup"`
+	Setup      []string `json:"setup"`
+	Teardown   []string `json:"teardown"`
+}
+
+// ReadPlan reads the JSON test plan file fn. Unknown keys are reported as error.
+func ReadPlan(fn string) (*Plan, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.DisallowUnknownFields()
+	plan := &Plan{}
+	if err := d.Decode(plan); err != nil {
+		return nil, fmt.Errorf("plan %s: %s", fn, err)
+	}
+	if plan.Name == "" {
+		plan.Name = strings.TrimSuffix(filepath.Base(fn), filepath.Ext(fn))
+	}
+	return plan, nil
+}
+
+// ScenarioResult is the structure used to provide the result of a test plan scenario.
+type ScenarioResult struct {
+	Name   string
+	Result *TestResult
+	Error  string // setup, test or teardown error
+}
+
+func (r *ScenarioResult) String() string {
+	if r.Error != "" {
+		return fmt.Sprintf("scenario %s: %s", r.Name, r.Error)
+	}
+	return fmt.Sprintf("scenario %s: %s", r.Name, r.Result)
+}
+
+// PlanResult is the structure used to provide the result of a test plan.
+type PlanResult struct {
+	Name      string
+	Scenarios []*ScenarioResult
+	NumError  int
+	Error     string
+}
+
+func (r *PlanResult) String() string {
+	if r.Error != "" {
+		return fmt.Sprintf("plan %s: %s", r.Name, r.Error)
+	}
+	return fmt.Sprintf("plan %s: %d scenarios - %d failed", r.Name, len(r.Scenarios), r.NumError)
+}
+
+// Results returns the test results of the plan scenarios.
+func (r *PlanResult) Results() []*TestResult {
+	results := []*TestResult{}
+	for _, s := range r.Scenarios {
+		if s.Result != nil {
+			results = append(results, s.Result)
+		}
+	}
+	return results
+}
+
+// testPrm returns the test parameters of the scenario.
+func (s *Scenario) testPrm(plan *Plan) (*testPrm, error) {
+	prm, err := newTestPrm(&urlQuery{}) // command-line flags only
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range []struct {
+		name  string
+		value int
+	}{
+		{"batchCount", s.BatchCount}, {"batchSize", s.BatchSize}, {"workers", s.Workers}, {"bufferSize", s.BufferSize},
+		{"bulkSize", s.BulkSize}, {"fetchSize", s.FetchSize}, {"timeout", s.Timeout}, {"repeat", s.Repeat}, {"warmup", s.Warmup},
+	} {
+		if v.value < 0 {
+			return nil, fmt.Errorf("invalid %s %d", v.name, v.value)
+		}
+	}
+
+	if s.BatchCount != 0 {
+		prm.batchCount = s.BatchCount
+	}
+	if s.BatchSize != 0 {
+		prm.batchSize = s.BatchSize
+	}
+	if s.BufferSize != 0 {
+		prm.bufferSize = s.BufferSize
+	}
+	if s.FetchSize != 0 {
+		prm.fetchSize = s.FetchSize
+	}
+	if s.Drop != nil {
+		prm.drop = *s.Drop
+	}
+	if s.Separate != nil {
+		prm.separate = *s.Separate
+	}
+	if s.Wait != nil {
+		if *s.Wait < 0 {
+			return nil, fmt.Errorf("invalid wait %d seconds", *s.Wait)
+		}
+		prm.wait = time.Duration(*s.Wait) * time.Second
+	}
+	if s.Repeat != 0 {
+		prm.repeat = s.Repeat
+	}
+	if plan.Label != "" {
+		prm.label = plan.Label
+	}
+	if plan.Seed != 0 {
+		prm.seed = plan.Seed
+	}
+	prm.workers, prm.bulkSize, prm.warmup = s.Workers, s.BulkSize, s.Warmup
+	return prm, nil
+}
+
+// checkPlan validates all scenarios of plan before any scenario is executed.
+func (h *TestHandler) checkPlan(plan *Plan) error {
+	if len(plan.Scenarios) == 0 {
+		return fmt.Errorf("no scenarios defined")
+	}
+	names := map[string]bool{}
+	for i, s := range plan.Scenarios {
+		if s.Name == "" {
+			return fmt.Errorf("scenario %d: name missing", i+1)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("scenario %s: duplicate name", s.Name)
+		}
+		names[s.Name] = true
+		if _, ok := h.testFuncs[testPath(s.Test)]; !ok {
+			return fmt.Errorf("scenario %s: invalid test %s", s.Name, s.Test)
+		}
+		if _, err := s.testPrm(plan); err != nil {
+			return fmt.Errorf("scenario %s: %s", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// RunPlan validates plan and executes the plan scenarios in order without HTTP server.
+// The plan is aborted when ctx is done.
+func (h *TestHandler) RunPlan(ctx context.Context, plan *Plan) *PlanResult {
+	r := &PlanResult{Name: plan.Name, Scenarios: []*ScenarioResult{}}
+	if err := h.checkPlan(plan); err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	for _, s := range plan.Scenarios {
+		if err := ctx.Err(); err != nil {
+			r.Error = err.Error()
+			break
+		}
+		sr := h.runScenario(ctx, plan, s)
+		h.log("%s", sr)
+		r.Scenarios = append(r.Scenarios, sr)
+		if sr.Error != "" {
+			r.NumError++
+		}
+	}
+	return r
+}
+
+// runScenario executes the setup statements, the test and the teardown statements of scenario s.
+// The teardown statements are executed even if the test failed.
+func (h *TestHandler) runScenario(ctx context.Context, plan *Plan, s *Scenario) *ScenarioResult {
+	r := &ScenarioResult{Name: s.Name}
+
+	prm, err := s.testPrm(plan)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	ctx, end, err := h.startRun(ctx, time.Duration(s.Timeout)*time.Second)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	defer end()
+
+	db, _, err := h.setup(prm)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	defer db.Close()
+
+	if err := h.execScenarioSQL(ctx, db, s.Setup); err != nil {
+		r.Error = fmt.Sprintf("setup: %s", err)
+		return r
+	}
+	defer func() {
+		// teardown even if the scenario context is done - aborted on shutdown only
+		if err := h.execScenarioSQL(h.ctx, db, s.Teardown); err != nil && r.Error == "" {
+			r.Error = fmt.Sprintf("teardown: %s", err)
+		}
+	}()
+
+	r.Result = h.run(ctx, newMonitor(), testPath(s.Test), prm)
+	r.Error = r.Result.Error
+	return r
+}
+
+// execScenarioSQL executes the scenario SQL statements in order.
+func (h *TestHandler) execScenarioSQL(ctx context.Context, db *sql.DB, stmts []string) error {
+	r := strings.NewReplacer("${schema}", driver.Identifier(h.schemaName).String(), "${table}", driver.Identifier(h.tableName).String())
+	for _, stmt := range stmts {
+		stmt = r.Replace(stmt)
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %s", stmt, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	writePlan := func(s string) string {
+		fn := filepath.Join(t.TempDir(), "nightly.json")
+		if err := os.WriteFile(fn, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+
+	plan, err := ReadPlan(writePlan(`{
+	"label": "v1",
+	"seed": 42,
+	"scenarios": [
+		{"name": "bulk", "test": "BulkSeq", "batchCount": 10, "batchSize": 1000, "bulkSize": 100, "drop": false, "wait": 2, "repeat": 5,
+			"setup": ["truncate table ${schema}.${table}"]},
+		{"name": "many", "test": "ManyPar", "workers": 4}
+	]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Name != "nightly" || len(plan.Scenarios) != 2 {
+		t.Fatalf("plan %s scenarios %d - expected nightly and 2", plan.Name, len(plan.Scenarios))
+	}
+
+	prm, err := plan.Scenarios[0].testPrm(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prm.batchCount != 10 || prm.batchSize != 1000 || prm.bulkSize != 100 || prm.drop || prm.wait != 2*time.Second ||
+		prm.repeat != 5 || prm.label != "v1" || prm.seed != 42 {
+		t.Fatalf("invalid test parameters %+v", prm)
+	}
+
+	if _, err := ReadPlan(writePlan(`{"scenarios": [{"name": "bulk", "tset": "BulkSeq"}]}`)); err == nil || !strings.Contains(err.Error(), "tset") {
+		t.Fatalf("error %v - expected unknown field tset", err)
+	}
+
+	h := &TestHandler{testFuncs: map[string]testFunc{TestBulkSeq: nil, TestManyPar: nil}}
+	if err := h.checkPlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	for _, scenarios := range [][]*Scenario{
+		nil,
+		{{Test: "BulkSeq"}},
+		{{Name: "a", Test: "BulkSeq"}, {Name: "a", Test: "BulkSeq"}},
+		{{Name: "a", Test: "BulkSeqq"}},
+		{{Name: "a", Test: "BulkSeq", BatchSize: -1}},
+	} {
+		if err := h.checkPlan(&Plan{Scenarios: scenarios}); err == nil {
+			t.Fatalf("scenarios %v: error expected", scenarios)
+		}
+	}
+}
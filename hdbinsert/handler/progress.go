@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// defStreamInterval is the default interval of progress events.
+const defStreamInterval = time.Second
+
+// workerProgress tracks the progress of a test worker.
+type workerProgress struct {
+	numBatch int
+	done     int64 // number of finished batches - accessed atomically
+	finished int32 // accessed atomically
+}
+
+// startWorkers registers the test workers with the number of batches assigned to each worker.
+func (m *monitor) startWorkers(numBatch ...int) {
+	progress := make([]*workerProgress, len(numBatch))
+	for i, n := range numBatch {
+		progress[i] = &workerProgress{numBatch: n}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.progress = progress
+}
+
+func (m *monitor) workerProgress(worker int) *workerProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if worker >= len(m.progress) {
+		return nil
+	}
+	return m.progress[worker]
+}
+
+// batchDone records a finished batch of worker.
+func (m *monitor) batchDone(worker int) {
+	if p := m.workerProgress(worker); p != nil {
+		atomic.AddInt64(&p.done, 1)
+	}
+}
+
+// workerFinished records that worker finished all of its batches.
+func (m *monitor) workerFinished(worker int) {
+	if p := m.workerProgress(worker); p != nil {
+		atomic.StoreInt32(&p.finished, 1)
+	}
+}
+
+// WorkerProgress is the structure used to provide the progress of a test worker.
+type WorkerProgress struct {
+	Worker   int
+	NumBatch int // number of batches assigned to the worker
+	Done     int // number of finished batches
+	Finished bool
+}
+
+// workerStatus returns the progress of the test workers.
+func (m *monitor) workerStatus() []*WorkerProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	workers := make([]*WorkerProgress, len(m.progress))
+	for i, p := range m.progress {
+		workers[i] = &WorkerProgress{Worker: i, NumBatch: p.numBatch, Done: int(atomic.LoadInt64(&p.done)), Finished: atomic.LoadInt32(&p.finished) != 0}
+	}
+	return workers
+}
+
+// Progress is the structure used to provide the progress of a running test (see stream URL query parameter).
+type Progress struct {
+	Test          string
+	Elapsed       time.Duration
+	NumRow        int64 // number of rows processed so far
+	NumFailedRow  int64
+	TotalRow      int64   // number of rows to be processed (0 for soak tests)
+	Percent       float64 // of rows processed (soak tests: of the test duration elapsed)
+	RowsPerSecond float64 // since the last progress event
+	Workers       []*WorkerProgress
+}
+
+// progressTracker creates the progress events of a running test.
+type progressTracker struct {
+	m       *monitor
+	test    string
+	prm     *testPrm
+	start   time.Time
+	last    time.Time
+	lastRow int64
+}
+
+func newProgressTracker(m *monitor, test string, prm *testPrm) *progressTracker {
+	now := time.Now()
+	return &progressTracker{m: m, test: test, prm: prm, start: now, last: now}
+}
+
+// progress returns the progress of the test at time now.
+func (t *progressTracker) progress(now time.Time) *Progress {
+	p := &Progress{
+		Test:         t.test,
+		Elapsed:      now.Sub(t.start),
+		NumRow:       t.m.rows(),
+		NumFailedRow: t.m.failedRows(),
+		Workers:      t.m.workerStatus(),
+	}
+	if IsSoakTest(t.test) {
+		if t.prm.duration > 0 {
+			p.Percent = float64(p.Elapsed) / float64(t.prm.duration) * 100
+		}
+	} else {
+		p.TotalRow = int64(t.prm.batchCount) * int64(t.prm.batchSize)
+		if p.TotalRow > 0 {
+			p.Percent = float64(p.NumRow+p.NumFailedRow) / float64(p.TotalRow) * 100
+		}
+	}
+	p.Percent = math.Min(p.Percent, 100)
+
+	numRow := p.NumRow
+	if numRow < t.lastRow { // monitor reset by repeated test runs
+		t.lastRow = 0
+	}
+	if d := now.Sub(t.last); d > 0 {
+		p.RowsPerSecond = float64(numRow-t.lastRow) / d.Seconds()
+	}
+	t.last, t.lastRow = now, numRow
+	return p
+}
+
+// writeEvent writes a Server-Sent Event with JSON encoded data.
+func writeEvent(w io.Writer, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// startStream sets the Server-Sent Events response headers and returns the flusher of w.
+// In case w does not support flushing an error response is written and false is returned.
+func startStream(w http.ResponseWriter) (http.Flusher, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return nil, false
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	return flusher, true
+}
+
+// streamResult streams the result of a test which was not executed (e.g. invalid parameters) as single
+// Server-Sent Event (event result).
+func streamResult(w http.ResponseWriter, result *TestResult) {
+	flusher, ok := startStream(w)
+	if !ok {
+		return
+	}
+	writeEvent(w, "result", result) // ignore error
+	flusher.Flush()
+}
+
+// stream executes a test and streams its progress as Server-Sent Events (event progress) in intervals
+// followed by the test result (event result). The test is aborted when the client closes the connection.
+func (h *TestHandler) stream(w http.ResponseWriter, r *http.Request, test string, prm *testPrm, timeout, interval time.Duration) {
+	flusher, ok := startStream(w)
+	if !ok {
+		return
+	}
+	if interval <= 0 {
+		interval = defStreamInterval
+	}
+
+	var result *TestResult
+	ctx, end, err := h.startRun(r.Context(), timeout)
+	if err != nil {
+		result = &TestResult{Test: test, BatchCount: prm.batchCount, BatchSize: prm.batchSize, Error: err.Error()}
+	} else {
+		m := newMonitor()
+		tracker := newProgressTracker(m, test, prm)
+		done := make(chan *TestResult)
+		go func(ctx context.Context) {
+			defer end()
+			done <- h.run(ctx, m, test, prm)
+		}(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for result == nil {
+			select {
+			case result = <-done:
+			case now := <-ticker.C:
+				writeEvent(w, "progress", tracker.progress(now)) // ignore error
+				flusher.Flush()
+			}
+		}
+	}
+	h.log("%s", result)
+	writeEvent(w, "result", result) // ignore error
+	flusher.Flush()
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	m := newMonitor()
+	prm := &testPrm{batchCount: 4, batchSize: 100}
+	tracker := newProgressTracker(m, TestBulkPar, prm)
+
+	m.startWorkers(2, 2)
+	m.exec(time.Millisecond, 150)
+	m.batchDone(0)
+	m.batchDone(0)
+	m.workerFinished(0)
+	m.batchDone(5) // unknown worker
+
+	p := tracker.progress(tracker.start.Add(time.Second))
+	if p.NumRow != 150 || p.TotalRow != 400 || p.Percent != 37.5 || p.RowsPerSecond != 150 {
+		t.Fatalf("rows %d total %d percent %f rows/s %f - expected 150 400 37.5 150", p.NumRow, p.TotalRow, p.Percent, p.RowsPerSecond)
+	}
+	if len(p.Workers) != 2 {
+		t.Fatalf("number of workers %d - expected 2", len(p.Workers))
+	}
+	if w := p.Workers[0]; w.Done != 2 || !w.Finished {
+		t.Fatalf("worker 0: done %d finished %t - expected 2 true", w.Done, w.Finished)
+	}
+	if w := p.Workers[1]; w.Done != 0 || w.Finished {
+		t.Fatalf("worker 1: done %d finished %t - expected 0 false", w.Done, w.Finished)
+	}
+
+	// soak tests: percent of duration elapsed
+	prm = &testPrm{batchCount: 4, batchSize: 100, duration: 10 * time.Second}
+	tracker = newProgressTracker(m, TestSoakBulk, prm)
+	if p := tracker.progress(tracker.start.Add(2 * time.Second)); p.Percent != 20 || p.TotalRow != 0 {
+		t.Fatalf("soak test percent %f total %d - expected 20 0", p.Percent, p.TotalRow)
+	}
+
+	b := new(bytes.Buffer)
+	if err := writeEvent(b, "result", &TestResult{Test: TestBulkSeq}); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !bytes.HasPrefix(b.Bytes(), []byte("event: result\ndata: {")) || !bytes.HasSuffix(b.Bytes(), []byte("}\n\n")) {
+		t.Fatalf("invalid event %q", s)
+	}
+}
+
+func TestStreamInvalidParameter(t *testing.T) {
+	h := &TestHandler{log: t.Logf}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", TestBulkSeq+"?stream=true&wait=x", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %s - expected text/event-stream", ct)
+	}
+	if s := w.Body.String(); !strings.HasPrefix(s, "event: result\ndata: {") || !strings.Contains(s, `"Error":"invalid url query value wait: x"`) {
+		t.Fatalf("invalid event %q", s)
+	}
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResultPath is the URL path of the stored test results.
+const ResultPath = "/results/"
+
+var errNoResultStore = errors.New("test results are not persisted (see resultFile command-line flag)")
+
+// resultError is the structure used to provide the JSON based error response of the result handler.
+type resultError struct {
+	Error string
+}
+
+// ResultHandler implements the http.Handler interface for the stored test results.
+type ResultHandler struct {
+	log   logFunc
+	store *resultStore
+}
+
+// NewResultHandler returns a new ResultHandler instance.
+func NewResultHandler(log logFunc, testHandler *TestHandler) (*ResultHandler, error) {
+	return &ResultHandler{log: log, store: testHandler.store}, nil
+}
+
+// ServeHTTP handles the URL paths
+//
+//	/results/      (list stored results)
+//	/results/<id>  (stored result)
+//
+// Listed results can be filtered by the URL query parameters label, test, batchcount, batchsize,
+// driverversion, hdbversion, since, until (RFC3339 or date) and limit (latest results).
+// The results are provided as JSON or as CSV, benchstat or JUnit XML (see URL query parameter format
+// or Accept header).
+func (h *ResultHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e := json.NewEncoder(w)
+
+	q := newURLQuery(r)
+	format, err := resultFormat(r, q)
+	if err != nil {
+		e.Encode(&resultError{Error: err.Error()}) // ignore error
+		return
+	}
+
+	if h.store == nil {
+		e.Encode(&resultError{Error: errNoResultStore.Error()}) // ignore error
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, ResultPath), "/")
+	if path == "" {
+		results, err := h.store.list(newResultFilter(q))
+		if err != nil {
+			h.log("%s", err)
+			e.Encode(&resultError{Error: err.Error()}) // ignore error
+			return
+		}
+		if format == formatJSON {
+			e.Encode(results) // ignore error
+			return
+		}
+		testResults := make([]*TestResult, len(results))
+		for i, r := range results {
+			testResults[i] = r.Result
+		}
+		writeResults(w, format, testResults) // ignore error
+		return
+	}
+
+	id, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		e.Encode(&resultError{Error: fmt.Sprintf("Invalid result %s", path)}) // ignore error
+		return
+	}
+	result, err := h.store.get(id)
+	if err != nil {
+		e.Encode(&resultError{Error: err.Error()}) // ignore error
+		return
+	}
+	if format == formatJSON {
+		e.Encode(result) // ignore error
+		return
+	}
+	writeResults(w, format, []*TestResult{result.Result}) // ignore error
+}
+
+// newResultFilter returns the result filter defined by the URL query.
+func newResultFilter(q *urlQuery) *resultFilter {
+	f := &resultFilter{
+		batchCount: q.getInt(urlQueryBatchCount, 0),
+		batchSize:  q.getInt(urlQueryBatchSize, 0),
+		since:      q.getTime(urlQuerySince, time.Time{}),
+		until:      q.getTime(urlQueryUntil, time.Time{}),
+		limit:      q.getInt(urlQueryLimit, 0),
+	}
+	f.label = q.getString(urlQueryLabel, "")
+	f.test = q.getString(urlQueryTest, "")
+	f.driverVersion = q.getString(urlQueryDriverVersion, "")
+	f.hdbVersion = q.getString(urlQueryHDBVersion, "")
+	return f
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// getSelectQuery returns the query selecting the rows of a batch. As the rows are selected by a range
+// of the first table column (key), the first column is expected to contain the row index (seq generator).
+func getSelectQuery(schemaName, tableName string, columns []*column, key string) string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = driver.Identifier(c.Name).String()
+	}
+	return fmt.Sprintf("select %s from %s.%s where %s >= ? and %s < ?", strings.Join(names, ", "), driver.Identifier(schemaName), driver.Identifier(tableName), driver.Identifier(key), driver.Identifier(key))
+}
+
+// selectColumns returns the projected columns of a select test and sets the monitor row size accordingly.
+// As the rows are selected by a range of the key column, an error is returned if the key column does not
+// contain the row index.
+func (h *TestHandler) selectColumns(m *monitor, prm *testPrm) ([]*column, error) {
+	if err := h.table.checkKey(); err != nil {
+		return nil, err
+	}
+	columns, err := h.table.projection(prm.columns)
+	if err != nil {
+		return nil, err
+	}
+	m.rowSize = 0
+	for _, c := range columns {
+		m.rowSize += c.size
+	}
+	return columns, nil
+}
+
+// selectBatch selects and fetches the rows of batch i. start is the start time of the test used to
+// calculate the time to the first fetched row.
+func selectBatch(ctx context.Context, m *monitor, stmt *sql.Stmt, numColumn int, prm *testPrm, i int, start time.Time) (time.Duration, error) {
+	dest := make([]interface{}, numColumn)
+	for j := range dest {
+		dest[j] = new(interface{})
+	}
+
+	t := time.Now()
+	rows, err := stmt.QueryContext(ctx, i*prm.batchSize, (i+1)*prm.batchSize)
+	if err != nil {
+		return time.Since(t), err
+	}
+	defer rows.Close()
+
+	numRow := 0
+	for rows.Next() {
+		if numRow == 0 {
+			m.fetched(time.Since(start))
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return time.Since(t), err
+		}
+		numRow++
+	}
+	if err := rows.Err(); err != nil {
+		return time.Since(t), err
+	}
+	d := time.Since(t)
+	m.exec(d, numRow)
+	return d, nil
+}
+
+func (h *TestHandler) selectSeq(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+	columns, err := h.selectColumns(m, prm)
+	if err != nil {
+		return 0, err
+	}
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	stmt, err := conn.PrepareContext(ctx, getSelectQuery(h.schemaName, h.tableName, columns, h.table.key().Name))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	defer m.startWorker()()
+	m.startWorkers(prm.batchCount)
+	defer m.workerFinished(0)
+
+	var d time.Duration
+
+	start := time.Now()
+	for i := 0; i < prm.batchCount; i++ {
+		e, err := selectBatch(ctx, m, stmt, len(columns), prm, i, start)
+		d += e
+		if err != nil {
+			return d, err
+		}
+		m.batchDone(0)
+	}
+	return d, nil
+}
+
+func (h *TestHandler) selectPar(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+	columns, err := h.selectColumns(m, prm)
+	if err != nil {
+		return 0, err
+	}
+
+	tasks, err := newTasks(ctx, db, prm)
+	if err != nil {
+		return 0, err
+	}
+	defer closeTasks(tasks)
+
+	for i := 0; i < prm.batchCount; i++ {
+		tableName := h.tableName
+		// use separate table for each batch
+		if prm.separate {
+			tableName = fmt.Sprintf("%s_%d", h.tableName, i)
+		}
+		if err := tasks[i%len(tasks)].addBatch(ctx, getSelectQuery(h.schemaName, tableName, columns, h.table.key().Name), nil, tableName, i*prm.batchSize); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	d := runTasks(m, tasks, func(worker int, t *task, b *taskBatch) {
+		if _, err := selectBatch(ctx, m, b.stmt, len(columns), prm, b.offset/prm.batchSize, start); err != nil {
+			m.failBatch(worker, b.table, b.offset, prm.batchSize, err)
+		}
+	})
+
+	return d, m.err()
+}
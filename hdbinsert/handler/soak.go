@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// IsSoakTest returns true if test is a time based soak test.
+func IsSoakTest(test string) bool { return test == TestSoakBulk || test == TestSoakMany }
+
+// SoakWindow is the structure used to provide the throughput of a soak test time window.
+type SoakWindow struct {
+	Start           time.Duration // window start relative to the test start
+	Duration        time.Duration
+	NumRow          int64
+	NumFailedRow    int64
+	RowsPerSecond   float64
+	MBPerSecond     float64
+	Latency         *LatencyResult
+	HeapAlloc       uint64 // allocated heap bytes at the end of the window
+	NumGoroutine    int    // number of goroutines at the end of the window
+	OpenConnections int    // number of open database connections at the end of the window
+}
+
+func (w *SoakWindow) String() string {
+	return fmt.Sprintf("%s-%s: %d rows - %.0f rows/s - %.2f MB/s - p99 %s - failed %d - heap %d goroutines %d connections %d",
+		w.Start, w.Start+w.Duration, w.NumRow, w.RowsPerSecond, w.MBPerSecond, w.Latency.P99, w.NumFailedRow, w.HeapAlloc, w.NumGoroutine, w.OpenConnections)
+}
+
+// rateLimiter paces the batches of all soak test workers to a target rate in rows per second.
+// A nil rateLimiter does not limit the rate.
+type rateLimiter struct {
+	start     time.Time
+	rate      int
+	scheduled int64 // rows scheduled so far - accessed atomically
+}
+
+func newRateLimiter(start time.Time, rate int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{start: start, rate: rate}
+}
+
+// wait blocks until a batch of numRow rows is due. It returns false if ctx is done
+// or if the batch would be due after end.
+func (l *rateLimiter) wait(ctx context.Context, numRow int, end time.Time) bool {
+	if l == nil {
+		return true
+	}
+	n := atomic.AddInt64(&l.scheduled, int64(numRow)) - int64(numRow)
+	due := l.start.Add(time.Duration(float64(n) / float64(l.rate) * float64(time.Second)))
+	if !due.Before(end) {
+		return false
+	}
+	return sleep(ctx, time.Until(due)) == nil
+}
+
+// windowSampler records the throughput of a soak test per time window.
+type windowSampler struct {
+	m       *monitor
+	db      *sql.DB
+	start   time.Time
+	last    time.Time
+	numRow  int64
+	numByte int64
+	failed  int64
+	windows []*SoakWindow
+}
+
+func newWindowSampler(m *monitor, db *sql.DB, start time.Time) *windowSampler {
+	m.window.Store(newHistogram())
+	return &windowSampler{m: m, db: db, start: start, last: start, windows: []*SoakWindow{}}
+}
+
+// sample closes the current window at time now and starts a new one.
+func (s *windowSampler) sample(now time.Time) {
+	latency := s.m.window.Load().(*histogram)
+	s.m.window.Store(newHistogram())
+
+	numRow, numByte, failed := s.m.rows(), s.m.bytes(), s.m.failedRows()
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	w := &SoakWindow{
+		Start:           s.last.Sub(s.start),
+		Duration:        now.Sub(s.last),
+		NumRow:          numRow - s.numRow,
+		NumFailedRow:    failed - s.failed,
+		Latency:         latency.result(),
+		HeapAlloc:       memStats.HeapAlloc,
+		NumGoroutine:    runtime.NumGoroutine(),
+		OpenConnections: s.db.Stats().OpenConnections,
+	}
+	if w.Duration > 0 {
+		w.RowsPerSecond = float64(w.NumRow) / w.Duration.Seconds()
+		w.MBPerSecond = float64(numByte-s.numByte) / w.Duration.Seconds() / 1e6
+	}
+	s.windows = append(s.windows, w)
+	s.last, s.numRow, s.numByte, s.failed = now, numRow, numByte, failed
+}
+
+// run samples the windows of duration d until stop is closed. The last (partial) window is sampled on stop.
+func (s *windowSampler) run(d time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			s.sample(now)
+		case <-stop:
+			if now := time.Now(); now.Sub(s.last) > 0 {
+				s.sample(now)
+			}
+			return
+		}
+	}
+}
+
+func (h *TestHandler) soakBulk(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+	return h.soak(ctx, m, db, prm, true)
+}
+
+func (h *TestHandler) soakMany(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error) {
+	return h.soak(ctx, m, db, prm, false)
+}
+
+// soak inserts batches of batchSize rows continuously via the parallel test workers for the soak test
+// duration (limited to the target rate if defined) and records the throughput per time window.
+// Each worker is inserting the same batch repeatedly, so that the row generation does not limit the throughput.
+func (h *TestHandler) soak(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm, bulk bool) (time.Duration, error) {
+	if prm.duration <= 0 {
+		return 0, fmt.Errorf("invalid soak test duration %s", prm.duration)
+	}
+	if prm.window <= 0 || prm.window > prm.duration {
+		prm.window = prm.duration
+	}
+
+	tasks, err := newTasks(ctx, db, prm)
+	if err != nil {
+		return 0, err
+	}
+	defer closeTasks(tasks)
+
+	for i, t := range tasks {
+		tableName := h.tableName
+		// use separate table for each worker
+		if prm.separate {
+			tableName = fmt.Sprintf("%s_%d", h.tableName, i)
+		}
+		if i == 0 || prm.separate {
+			if err := h.prepareTable(ctx, db, prm, dmlInsert, tableName); err != nil {
+				return 0, err
+			}
+		}
+		query := dmlOp(dmlInsert).query(h.schemaName, tableName, h.table, bulk)
+		if err := t.addBatch(ctx, query, h.table.rows(prm.seed, i, prm.batchSize), tableName, i*prm.batchSize); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	end := start.Add(prm.duration)
+	limiter := newRateLimiter(start, prm.rate)
+
+	sampler := newWindowSampler(m, db, start)
+	stop, sampled := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(sampled)
+		sampler.run(prm.window, stop)
+	}()
+
+	d := runTasks(m, tasks, func(worker int, t *task, b *taskBatch) {
+		for ctx.Err() == nil && time.Now().Before(end) && limiter.wait(ctx, len(b.rows), end) {
+			if bulk {
+				execBulk(ctx, m, prm, worker, b)
+			} else {
+				execMany(ctx, m, prm, worker, b)
+			}
+		}
+	})
+
+	close(stop)
+	<-sampled
+	m.windows = sampler.windows
+
+	if err := m.err(); err != nil {
+		return d, err
+	}
+	return d, ctx.Err()
+}
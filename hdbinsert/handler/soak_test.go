@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	start := time.Now()
+
+	if l := newRateLimiter(start, 0); l != nil || !l.wait(ctx, 1000, start) {
+		t.Fatal("unlimited rate limiter expected")
+	}
+
+	// 1000 rows per second: batches of 100 rows are due every 100ms.
+	l := newRateLimiter(start, 1000)
+	end := start.Add(250 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if !l.wait(ctx, 100, end) {
+			t.Fatalf("batch %d: due before end expected", i)
+		}
+	}
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Fatalf("third batch executed after %s - expected >= 200ms", d)
+	}
+	if l.wait(ctx, 100, end) {
+		t.Fatal("batch due after end expected")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	l = newRateLimiter(time.Now(), 1)
+	l.wait(ctx, 1, time.Now().Add(time.Hour)) // first batch is due immediately
+	if l.wait(ctx, 1, time.Now().Add(time.Hour)) {
+		t.Fatal("wait of cancelled context expected to fail")
+	}
+}
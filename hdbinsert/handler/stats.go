@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"fmt"
+	"math"
+)
+
+// RunStats is the structure used to provide the duration statistics of repeated test runs.
+type RunStats struct {
+	Warmup  int       // number of discarded warmup runs
+	Seconds []float64 // durations of the measured runs
+	Mean    float64
+	StdDev  float64 // sample standard deviation
+	Median  float64
+	Min     float64
+	Max     float64
+	CILow   float64 // lower bound of the 95% confidence interval of the mean
+	CIHigh  float64 // upper bound of the 95% confidence interval of the mean
+}
+
+func (s *RunStats) String() string {
+	return fmt.Sprintf("%d runs (%d warmup): mean %fs stddev %fs median %fs min %fs max %fs 95%% CI [%fs, %fs]",
+		len(s.Seconds), s.Warmup, s.Mean, s.StdDev, s.Median, s.Min, s.Max, s.CILow, s.CIHigh)
+}
+
+// tQuantiles are the 0.975 quantiles of the Student's t-distribution for 1 to 30 degrees of freedom.
+var tQuantiles = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// tQuantile returns the 0.975 quantile of the Student's t-distribution for df degrees of freedom.
+// For more than 30 degrees of freedom the quantile of the next lower table entry (30, 40, 60 or 120)
+// is returned, so that the confidence interval is rather too wide than too narrow.
+func tQuantile(df int) float64 {
+	switch {
+	case df <= len(tQuantiles):
+		return tQuantiles[df-1]
+	case df < 40:
+		return tQuantiles[len(tQuantiles)-1]
+	case df < 60:
+		return 2.021
+	case df < 120:
+		return 2.000
+	default:
+		return 1.980
+	}
+}
+
+// newRunStats returns the statistics of the durations x (in seconds) of the measured runs.
+func newRunStats(warmup int, x []float64) *RunStats {
+	s := &RunStats{Warmup: warmup, Seconds: x}
+	n := len(x)
+	if n == 0 {
+		return s
+	}
+
+	s.Min, s.Max = x[0], x[0]
+	sum := 0.0
+	for _, v := range x {
+		sum += v
+		s.Min = math.Min(s.Min, v)
+		s.Max = math.Max(s.Max, v)
+	}
+	s.Mean = sum / float64(n)
+	s.Median = median(x)
+	s.CILow, s.CIHigh = s.Mean, s.Mean
+	if n == 1 {
+		return s
+	}
+
+	ss := 0.0
+	for _, v := range x {
+		ss += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StdDev = math.Sqrt(ss / float64(n-1))
+	hw := tQuantile(n-1) * s.StdDev / math.Sqrt(float64(n)) // half width
+	s.CILow, s.CIHigh = s.Mean-hw, s.Mean+hw
+	return s
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRunStats(t *testing.T) {
+	const eps = 1e-9
+
+	s := newRunStats(2, []float64{2, 4, 4, 4, 5, 5, 7, 9})
+
+	check := func(name string, v, expected float64) {
+		if math.Abs(v-expected) > eps {
+			t.Fatalf("%s %g - expected %g", name, v, expected)
+		}
+	}
+
+	check("mean", s.Mean, 5)
+	check("stddev", s.StdDev, math.Sqrt(32.0/7))
+	check("median", s.Median, 4.5)
+	check("min", s.Min, 2)
+	check("max", s.Max, 9)
+	hw := 2.365 * math.Sqrt(32.0/7) / math.Sqrt(8)
+	check("ci low", s.CILow, 5-hw)
+	check("ci high", s.CIHigh, 5+hw)
+
+	if s.Warmup != 2 || len(s.Seconds) != 8 {
+		t.Fatalf("warmup %d runs %d - expected 2 and 8", s.Warmup, len(s.Seconds))
+	}
+
+	// single run
+	s = newRunStats(0, []float64{3})
+	check("single mean", s.Mean, 3)
+	check("single stddev", s.StdDev, 0)
+	check("single ci low", s.CILow, 3)
+	check("single ci high", s.CIHigh, 3)
+
+	// no run
+	if s = newRunStats(0, nil); s.Mean != 0 || s.StdDev != 0 {
+		t.Fatalf("empty stats %v", s)
+	}
+}
+
+func TestTQuantile(t *testing.T) {
+	for _, test := range []struct {
+		df int
+		q  float64
+	}{
+		{1, 12.706}, {30, 2.042}, {31, 2.042}, {39, 2.042}, {40, 2.021}, {59, 2.021}, {60, 2.000}, {119, 2.000}, {120, 1.980}, {1000, 1.980},
+	} {
+		if q := tQuantile(test.df); q != test.q {
+			t.Fatalf("df %d: quantile %g - expected %g", test.df, q, test.q)
+		}
+	}
+}
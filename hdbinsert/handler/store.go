@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+// StoredResult is the structure used to persist test results in the result store.
+type StoredResult struct {
+	ID     int64
+	Time   time.Time
+	Flags  map[string]string // command-line flags
+	Result *TestResult
+}
+
+func (r *StoredResult) String() string {
+	return fmt.Sprintf("run %d %s: %s", r.ID, r.Time.Format(time.RFC3339), r.Result)
+}
+
+// envFlags returns the name value map of the command-line flags.
+func envFlags() map[string]string {
+	flags := map[string]string{}
+	env.Visit(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+	return flags
+}
+
+// resultFilter defines the criteria test results are selected by.
+// Empty or zero fields are not considered.
+type resultFilter struct {
+	label                 string
+	test                  string // test name (e.g. BulkSeq)
+	batchCount, batchSize int
+	driverVersion         string
+	hdbVersion            string
+	since, until          time.Time
+	limit                 int // maximum number of (latest) results
+}
+
+func (f *resultFilter) match(r *StoredResult) bool {
+	switch {
+	case f.label != "" && r.Result.Label != f.label:
+		return false
+	case f.test != "" && !strings.EqualFold(path.Base(r.Result.Test), path.Base(f.test)):
+		return false
+	case f.batchCount != 0 && r.Result.BatchCount != f.batchCount:
+		return false
+	case f.batchSize != 0 && r.Result.BatchSize != f.batchSize:
+		return false
+	case f.driverVersion != "" && r.Result.DriverVersion != f.driverVersion:
+		return false
+	case f.hdbVersion != "" && r.Result.HDBVersion != f.hdbVersion:
+		return false
+	case !f.since.IsZero() && r.Time.Before(f.since):
+		return false
+	case !f.until.IsZero() && !r.Time.Before(f.until):
+		return false
+	}
+	return true
+}
+
+// maxResultLine is the maximum size of a stored result in bytes.
+const maxResultLine = 1 << 20
+
+// resultStore persists test results in a file with one JSON encoded result per line (JSON lines).
+type resultStore struct {
+	fn  string
+	log logFunc
+
+	mu      sync.Mutex
+	lastID  int64
+	invalid map[int]bool // numbers of the invalid lines already logged
+}
+
+// newResultStore returns a result store persisting the test results in file fn.
+// The file is created with the first stored result. Invalid lines (e.g. partially written
+// results) are skipped and logged.
+func newResultStore(fn string, log logFunc) (*resultStore, error) {
+	s := &resultStore{fn: fn, log: log, invalid: map[int]bool{}}
+	err := s.scan(func(r *StoredResult) bool {
+		if r.ID > s.lastID {
+			s.lastID = r.ID
+		}
+		return true
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// scan calls f for each valid stored result until f returns false.
+func (s *resultStore) scan(f func(r *StoredResult) bool) error {
+	file, err := os.Open(s.fn)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 0, 64*1024), maxResultLine)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		r := &StoredResult{}
+		if err := json.Unmarshal(sc.Bytes(), r); err != nil {
+			if !s.invalid[lineNo] {
+				s.invalid[lineNo] = true
+				s.log("result file %s line %d skipped: %s", s.fn, lineNo, err)
+			}
+			continue
+		}
+		if r.Result == nil {
+			r.Result = &TestResult{}
+		}
+		if !f(r) {
+			break
+		}
+	}
+	return sc.Err()
+}
+
+// add stores the test result and returns the stored result.
+// The result run id is set to the id of the stored result.
+func (s *resultStore) add(result *TestResult) (r *StoredResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r = &StoredResult{ID: s.lastID + 1, Time: time.Now().UTC(), Flags: envFlags(), Result: result}
+	result.RunID = r.ID
+	defer func() {
+		if err != nil {
+			result.RunID = 0
+		}
+	}()
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(s.fn, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// start a new line in case the last line was not terminated (partial write)
+	if fi, err := file.Stat(); err == nil && fi.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, fi.Size()-1); err == nil && last[0] != '\n' {
+			b = append([]byte{'\n'}, b...)
+		}
+	}
+	if _, err := file.Write(append(b, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	s.lastID = r.ID
+	return r, nil
+}
+
+// get returns the stored result with id.
+func (s *resultStore) get(id int64) (*StoredResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result *StoredResult
+	err := s.scan(func(r *StoredResult) bool {
+		if r.ID == id {
+			result = r
+			return false
+		}
+		return true
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("result %d not found", id)
+	}
+	return result, nil
+}
+
+// list returns the stored results matching filter ordered by id.
+func (s *resultStore) list(filter *resultFilter) ([]*StoredResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := []*StoredResult{}
+	err := s.scan(func(r *StoredResult) bool {
+		if filter.match(r) {
+			results = append(results, r)
+		}
+		return true
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if filter.limit > 0 && len(results) > filter.limit {
+		results = results[len(results)-filter.limit:]
+	}
+	return results, nil
+}
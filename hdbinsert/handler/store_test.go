@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResultStore(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "results.jsonl")
+
+	s, err := newResultStore(fn, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []*TestResult{
+		{Test: TestBulkSeq, BatchCount: 1, BatchSize: 1000, DriverVersion: "0.103.1"},
+		{Test: TestManySeq, BatchCount: 10, BatchSize: 100, DriverVersion: "0.103.1"},
+		{Test: TestBulkSeq, BatchCount: 10, BatchSize: 100, DriverVersion: "0.104.0"},
+	}
+	for i, result := range results {
+		r, err := s.add(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id := int64(i + 1); r.ID != id || result.RunID != id {
+			t.Fatalf("id %d run id %d - expected %d", r.ID, result.RunID, id)
+		}
+	}
+
+	// reopen store
+	if s, err = newResultStore(fn, t.Logf); err != nil {
+		t.Fatal(err)
+	}
+	if s.lastID != int64(len(results)) {
+		t.Fatalf("last id %d - expected %d", s.lastID, len(results))
+	}
+
+	r, err := s.get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Result.Test != TestManySeq || r.Result.BatchCount != 10 {
+		t.Fatalf("invalid result %s", r)
+	}
+	if _, err := s.get(4); err == nil {
+		t.Fatal("error expected")
+	}
+
+	filterTests := []struct {
+		filter *resultFilter
+		ids    []int64
+	}{
+		{&resultFilter{}, []int64{1, 2, 3}},
+		{&resultFilter{test: "bulkseq"}, []int64{1, 3}},
+		{&resultFilter{batchCount: 10, batchSize: 100}, []int64{2, 3}},
+		{&resultFilter{driverVersion: "0.103.1"}, []int64{1, 2}},
+		{&resultFilter{limit: 1}, []int64{3}},
+	}
+	for _, test := range filterTests {
+		l, err := s.list(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(l) != len(test.ids) {
+			t.Fatalf("filter %v: number of results %d - expected %d", test.filter, len(l), len(test.ids))
+		}
+		for i, r := range l {
+			if r.ID != test.ids[i] {
+				t.Fatalf("filter %v: id %d - expected %d", test.filter, r.ID, test.ids[i])
+			}
+		}
+	}
+}
+
+func TestResultStoreInvalidLine(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "results.jsonl")
+
+	s, err := newResultStore(fn, t.Logf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.add(&TestResult{Test: TestBulkSeq}); err != nil {
+		t.Fatal(err)
+	}
+
+	// simulate partial write
+	file, err := os.OpenFile(fn, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"ID":2,"Time":"2021-`); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs []string
+	log := func(format string, v ...interface{}) { logs = append(logs, fmt.Sprintf(format, v...)) }
+
+	if s, err = newResultStore(fn, log); err != nil {
+		t.Fatal(err)
+	}
+	if s.lastID != 1 {
+		t.Fatalf("last id %d - expected %d", s.lastID, 1)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("number of log entries %d - expected %d", len(logs), 1)
+	}
+	if prefix := fmt.Sprintf("result file %s line 2 skipped:", fn); !strings.HasPrefix(logs[0], prefix) {
+		t.Fatalf("invalid log entry %s", logs[0])
+	}
+
+	if _, err := s.add(&TestResult{Test: TestManySeq}); err != nil {
+		t.Fatal(err)
+	}
+	l, err := s.list(&resultFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l) != 2 || l[0].ID != 1 || l[1].ID != 2 || l[1].Result.Test != TestManySeq {
+		t.Fatalf("invalid results %v", l)
+	}
+	if len(logs) != 1 { // invalid line logged once
+		t.Fatalf("number of log entries %d - expected %d", len(logs), 1)
+	}
+}
@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// SweepResult is the structure used to provide the result of a buffer size and bulk size sweep of a test.
+// RowsPerSecond is a heatmap-ready table with one row per buffer size and one column per bulk size.
+type SweepResult struct {
+	Test          string
+	BatchCount    int
+	BatchSize     int
+	Workers       int `json:",omitempty"`
+	BufferSizes   []int
+	BulkSizes     []int
+	RowsPerSecond [][]float64   // [buffer size][bulk size] - 0 in case of a test error
+	Optimum       *TestResult   // test result with the highest number of rows per second
+	Results       []*TestResult // test results in grid order (buffer size by bulk size)
+	NumError      int
+	Error         string
+}
+
+func (s *SweepResult) String() string {
+	if s.Error != "" {
+		return s.Error
+	}
+	str := fmt.Sprintf("sweep %s %dx%d: %d buffer sizes x %d bulk sizes - %d errors", path.Base(s.Test), s.BatchCount, s.BatchSize, len(s.BufferSizes), len(s.BulkSizes), s.NumError)
+	if s.Optimum == nil {
+		return str + " - no successful test run"
+	}
+	return fmt.Sprintf("%s - optimum bufferSize %d bulkSize %d: %.0f rows/s", str, s.Optimum.BufferSize, s.Optimum.BulkSize, sweepRowsPerSecond(s.Optimum))
+}
+
+// sweepRowsPerSecond returns the rows per second of a test result. In case of repeated test runs
+// the rows per second are calculated based on the median duration.
+func sweepRowsPerSecond(r *TestResult) float64 {
+	if r.Stats != nil && r.Stats.Median > 0 {
+		return float64(r.NumRow) / r.Stats.Median
+	}
+	return r.RowsPerSecond
+}
+
+// checkSizes returns an error if sizes is empty or contains a size less or equal zero.
+func checkSizes(name string, sizes []int) error {
+	if len(sizes) == 0 {
+		return fmt.Errorf("no %s sizes defined", name)
+	}
+	for _, size := range sizes {
+		if size <= 0 {
+			return fmt.Errorf("invalid %s size %d", name, size)
+		}
+	}
+	return nil
+}
+
+// sweepBulkSizes returns the bulk sizes capped to the maximum bulk size of the driver. Bulk sizes mapping to the
+// same capped bulk size are folded into one, so that the sweep columns are labeled by the bulk sizes in effect.
+func sweepBulkSizes(bulkSizes []int) []int {
+	sizes := make([]int, 0, len(bulkSizes))
+	seen := map[int]bool{}
+	for _, size := range bulkSizes {
+		if size > driver.MaxBulkSize {
+			size = driver.MaxBulkSize
+		}
+		if !seen[size] {
+			seen[size] = true
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes
+}
+
+// Sweep executes test for all combinations of bufferSizes and bulkSizes with batchCount, batchSize, the number
+// of workers of parallel tests and the command-line flags as test parameters without HTTP server.
+func (h *TestHandler) Sweep(ctx context.Context, test string, batchCount, batchSize, workers int, bufferSizes, bulkSizes []int) *SweepResult {
+	prm, err := newTestPrm(&urlQuery{}) // command-line flags only
+	if err != nil {
+		return &SweepResult{Test: test, BatchCount: batchCount, BatchSize: batchSize, Workers: workers, Error: err.Error()}
+	}
+	prm.batchCount, prm.batchSize, prm.workers = batchCount, batchSize, workers
+	return h.sweep(ctx, test, prm, bufferSizes, bulkSizes, 0)
+}
+
+// sweep executes test for all combinations of bufferSizes and bulkSizes. All test runs are using the same seed,
+// so that the same rows are processed. Bulk sizes exceeding the maximum bulk size of the driver are capped
+// (see sweepBulkSizes). The sweep is aborted after timeout (if timeout > 0).
+func (h *TestHandler) sweep(ctx context.Context, test string, prm *testPrm, bufferSizes, bulkSizes []int, timeout time.Duration) *SweepResult {
+	s := &SweepResult{Test: test, BatchCount: prm.batchCount, BatchSize: prm.batchSize, Workers: prm.workers, BufferSizes: bufferSizes, BulkSizes: bulkSizes}
+
+	if _, ok := h.testFuncs[test]; !ok {
+		s.Error = fmt.Sprintf("Invalid test %s", test)
+		return s
+	}
+	if err := checkSizes("buffer", bufferSizes); err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	if err := checkSizes("bulk", bulkSizes); err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	bulkSizes = sweepBulkSizes(bulkSizes)
+	s.BulkSizes = bulkSizes
+
+	ctx, end, err := h.startRun(ctx, timeout)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	defer end()
+
+	if prm.seed == 0 {
+		prm.seed = time.Now().UnixNano()
+	}
+
+	s.RowsPerSecond = make([][]float64, len(bufferSizes))
+	s.Results = make([]*TestResult, 0, len(bufferSizes)*len(bulkSizes))
+	for i, bufferSize := range bufferSizes {
+		s.RowsPerSecond[i] = make([]float64, len(bulkSizes))
+		for j, bulkSize := range bulkSizes {
+			if err := ctx.Err(); err != nil {
+				s.Error = err.Error()
+				return s
+			}
+			runPrm := *prm
+			runPrm.bufferSize, runPrm.bulkSize = bufferSize, bulkSize
+			result := h.run(ctx, newMonitor(), test, &runPrm)
+			h.log("%s", result)
+			s.Results = append(s.Results, result)
+			if result.Error != "" {
+				s.NumError++
+				continue
+			}
+			s.RowsPerSecond[i][j] = sweepRowsPerSecond(result)
+			if s.Optimum == nil || s.RowsPerSecond[i][j] > sweepRowsPerSecond(s.Optimum) {
+				s.Optimum = result
+			}
+		}
+	}
+	return s
+}
+
+// WriteSweeps writes the sweep results in format (see ParseResultFormat) to w. The csv format is a heatmap table
+// with one line per sweep and buffer size and one rows per second column per bulk size. The benchstat and junit
+// formats contain the test results of the sweeps.
+func WriteSweeps(w io.Writer, format string, sweeps []*SweepResult) error {
+	format, err := ParseResultFormat(format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case formatJSON:
+		return json.NewEncoder(w).Encode(sweeps)
+	case formatCSV:
+		return writeSweepCSV(w, sweeps)
+	default:
+		results := []*TestResult{}
+		for _, s := range sweeps {
+			results = append(results, s.Results...)
+		}
+		return WriteResults(w, format, results)
+	}
+}
+
+// writeSweepCSV writes the rows per second of the sweeps as heatmap table. The header line is repeated
+// whenever the bulk sizes differ from the previous sweep.
+func writeSweepCSV(w io.Writer, sweeps []*SweepResult) error {
+	cw := csv.NewWriter(w)
+	itoa := strconv.Itoa
+	var header []string
+	for _, s := range sweeps {
+		if s.Error != "" {
+			continue
+		}
+		h := []string{"Test", "BatchCount", "BatchSize", "Workers", "BufferSize\\BulkSize"}
+		for _, bulkSize := range s.BulkSizes {
+			h = append(h, itoa(bulkSize))
+		}
+		if !equalStrings(h, header) {
+			if err := cw.Write(h); err != nil {
+				return err
+			}
+			header = h
+		}
+		for i, bufferSize := range s.BufferSizes {
+			record := []string{path.Base(s.Test), itoa(s.BatchCount), itoa(s.BatchSize), itoa(s.Workers), itoa(bufferSize)}
+			for _, rowsPerSecond := range s.RowsPerSecond[i] {
+				record = append(record, strconv.FormatFloat(rowsPerSecond, 'f', 0, 64))
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
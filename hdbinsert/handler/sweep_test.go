@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestWriteSweeps(t *testing.T) {
+	sweeps := []*SweepResult{
+		{Test: TestBulkSeq, BatchCount: 10, BatchSize: 1000, BufferSizes: []int{16276, 65536}, BulkSizes: []int{100, 1000}, RowsPerSecond: [][]float64{{1000, 2000}, {3000, 0}}},
+		{Test: TestBulkPar, Error: "failed"},
+		{Test: TestManySeq, BatchCount: 1, BatchSize: 1000, BufferSizes: []int{16276}, BulkSizes: []int{100, 1000}, RowsPerSecond: [][]float64{{500, 600}}},
+	}
+
+	b := new(bytes.Buffer)
+	if err := WriteSweeps(b, formatCSV, sweeps); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one header line (same bulk sizes), failed sweeps are skipped
+	expected := [][]string{
+		{"Test", "BatchCount", "BatchSize", "Workers", "BufferSize\\BulkSize", "100", "1000"},
+		{"BulkSeq", "10", "1000", "0", "16276", "1000", "2000"},
+		{"BulkSeq", "10", "1000", "0", "65536", "3000", "0"},
+		{"ManySeq", "1", "1000", "0", "16276", "500", "600"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("records %v - expected %v", records, expected)
+	}
+	for i, record := range records {
+		if !equalStrings(record, expected[i]) {
+			t.Fatalf("record %d %v - expected %v", i, record, expected[i])
+		}
+	}
+}
+
+func TestCheckSizes(t *testing.T) {
+	if err := checkSizes("bulk", []int{1, 1000}); err != nil {
+		t.Fatal(err)
+	}
+	for _, sizes := range [][]int{nil, {1000, 0}, {-1}} {
+		if err := checkSizes("bulk", sizes); err == nil {
+			t.Fatalf("sizes %v: error expected", sizes)
+		}
+	}
+}
+
+func TestSweepBulkSizes(t *testing.T) {
+	sizes := sweepBulkSizes([]int{1000, 32767, 65536, 100000, 100})
+	if expected := []int{1000, 32767, 100}; !reflect.DeepEqual(sizes, expected) {
+		t.Fatalf("bulk sizes %v - expected %v", sizes, expected)
+	}
+}
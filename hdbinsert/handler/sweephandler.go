@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+// SweepPath is the URL path of the buffer size and bulk size sweep.
+const SweepPath = "/sweep"
+
+// SweepHandler implements the http.Handler interface for buffer size and bulk size sweeps.
+type SweepHandler struct {
+	log         logFunc
+	testHandler *TestHandler
+}
+
+// NewSweepHandler returns a new SweepHandler instance.
+func NewSweepHandler(log logFunc, testHandler *TestHandler) (*SweepHandler, error) {
+	return &SweepHandler{log: log, testHandler: testHandler}, nil
+}
+
+// ServeHTTP handles the URL path
+//
+//	/sweep?test=<TestType>[&buffersizes=<list>][&bulksizes=<list>][&batchcount=<number>&batchsize=<number>][&workers=<number>][&timeout=<duration>][&format=<format>]
+//
+// The buffer sizes and bulk sizes are comma separated lists (default: sweepBufferSize and sweepBulkSize command-line flag).
+func (h *SweepHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := newURLQuery(r)
+	test := testPath(q.getString(urlQueryTest, ""))
+
+	prm, err := newTestPrm(q)
+	var bufferSizes, bulkSizes []int
+	if err == nil {
+		bufferSizes, err = q.getValidInts(urlQueryBufferSizes, env.SweepBufferSize().Sizes)
+	}
+	if err == nil {
+		bulkSizes, err = q.getValidInts(urlQueryBulkSizes, env.SweepBulkSize().Sizes)
+	}
+	var timeout time.Duration
+	if err == nil {
+		timeout, err = q.getValidDuration(urlQueryTimeout, 0)
+	}
+	format := formatJSON
+	if err == nil {
+		format, err = resultFormat(r, q)
+	}
+
+	var result *SweepResult
+	if err != nil {
+		result = &SweepResult{Test: test, BatchCount: prm.batchCount, BatchSize: prm.batchSize, Workers: prm.workers, Error: err.Error()}
+	} else {
+		result = h.testHandler.sweep(r.Context(), test, prm, bufferSizes, bulkSizes, timeout)
+	}
+	h.log("%s", result)
+
+	if format == formatJSON {
+		json.NewEncoder(w).Encode(result) // ignore error
+		return
+	}
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	WriteSweeps(w, format, []*SweepResult{result}) // ignore error
+}
@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/SAP/go-hdb/driver"
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+// column kinds
+const (
+	kindInt = iota
+	kindFloat
+	kindDecimal
+	kindBool
+	kindString
+	kindBytes
+	kindTime
+)
+
+type columnKind int
+
+// columnTypes maps the supported HANA data types to column kind and payload size in bytes.
+// Size 0 means that the size is defined by the type length or by the generator.
+var columnTypes = map[string]struct {
+	kind columnKind
+	size int
+}{
+	"TINYINT":      {kindInt, 1},
+	"SMALLINT":     {kindInt, 2},
+	"INTEGER":      {kindInt, 4},
+	"BIGINT":       {kindInt, 8},
+	"REAL":         {kindFloat, 4},
+	"DOUBLE":       {kindFloat, 8},
+	"FLOAT":        {kindFloat, 8},
+	"DECIMAL":      {kindDecimal, 16},
+	"SMALLDECIMAL": {kindDecimal, 8},
+	"BOOLEAN":      {kindBool, 1},
+	"VARCHAR":      {kindString, 0},
+	"NVARCHAR":     {kindString, 0},
+	"CHAR":         {kindString, 0},
+	"NCHAR":        {kindString, 0},
+	"ALPHANUM":     {kindString, 0},
+	"SHORTTEXT":    {kindString, 0},
+	"VARBINARY":    {kindBytes, 0},
+	"BINARY":       {kindBytes, 0},
+	"DATE":         {kindTime, 4},
+	"TIME":         {kindTime, 4},
+	"SECONDDATE":   {kindTime, 8},
+	"TIMESTAMP":    {kindTime, 8},
+}
+
+// defLength is the length used for string and binary columns without type length.
+const defLength = 10
+
+// defDecimalScale is the scale used for decimal columns without type scale (floating point decimal).
+const defDecimalScale = 6
+
+// maxDecimalScale is the maximum supported decimal scale.
+const maxDecimalScale = 18
+
+// splitCall splits a string of format name(arg1,arg2,...) into name and arguments.
+// Arguments might be nested calls themselves like null(0.1,uniform(0,1)).
+func splitCall(s string) (string, []string, error) {
+	i := strings.IndexByte(s, '(')
+	if i == -1 {
+		return s, nil, nil
+	}
+	if !strings.HasSuffix(s, ")") {
+		return "", nil, fmt.Errorf("invalid format: %s", s)
+	}
+	var args []string
+	depth, start := 0, i+1
+	for j := i + 1; j < len(s)-1; j++ {
+		switch s[j] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return "", nil, fmt.Errorf("invalid format: %s", s)
+			}
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:j]))
+				start = j + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return "", nil, fmt.Errorf("invalid format: %s", s)
+	}
+	args = append(args, strings.TrimSpace(s[start:len(s)-1]))
+	return s[:i], args, nil
+}
+
+func parseInts(s []string) ([]int, error) {
+	r := make([]int, len(s))
+	for i, v := range s {
+		var err error
+		if r[i], err = strconv.Atoi(v); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func parseFloats(s []string) ([]float64, error) {
+	r := make([]float64, len(s))
+	for i, v := range s {
+		var err error
+		if r[i], err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// column is a test table column.
+type column struct {
+	env.Column
+	kind   columnKind
+	length int // type length or scale for decimals
+	size   int // payload size in bytes
+	gen    FieldGenerator
+}
+
+func newColumn(c env.Column) (*column, error) {
+	typeName, args, err := splitCall(c.Type)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %s", c.Name, err)
+	}
+	typ, ok := columnTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("column %s: unsupported data type %s", c.Name, c.Type)
+	}
+	targs, err := parseInts(args)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: invalid data type %s", c.Name, c.Type)
+	}
+
+	col := &column{Column: c, kind: typ.kind, size: typ.size}
+
+	switch typ.kind {
+	case kindString, kindBytes:
+		col.length = defLength
+		if len(targs) > 0 {
+			if targs[0] <= 0 {
+				return nil, fmt.Errorf("column %s: invalid data type %s", c.Name, c.Type)
+			}
+			col.length = targs[0]
+		}
+	case kindDecimal:
+		switch len(targs) {
+		case 0:
+			col.length = defDecimalScale
+		case 1: // DECIMAL(p) is DECIMAL(p, 0)
+			col.length = 0
+		default:
+			if targs[1] < 0 || targs[1] > maxDecimalScale {
+				return nil, fmt.Errorf("column %s: invalid data type %s", c.Name, c.Type)
+			}
+			col.length = targs[1]
+		}
+	}
+
+	if col.gen, err = newFieldGenerator(col, col.Generator); err != nil {
+		return nil, fmt.Errorf("column %s: %s", c.Name, err)
+	}
+	if col.size == 0 {
+		col.size = col.length
+		if s, ok := col.gen.(sizer); ok {
+			col.size = s.size()
+		}
+	}
+	return col, nil
+}
+
+// convert converts a generated numeric value to the column data type.
+func (c *column) convert(v float64) interface{} {
+	switch c.kind {
+	case kindInt:
+		return int64(math.Round(v))
+	case kindDecimal:
+		// format with scale digits instead of scaling into an int64, which would overflow for large values
+		r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'f', c.length, 64))
+		return r
+	case kindBool:
+		return v >= 0.5
+	case kindString:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return v
+	}
+}
+
+// table represents the test table definition.
+type table struct {
+	columns []*column
+	rowSize int // payload size of a row in bytes
+}
+
+func newTable(columns []env.Column) (*table, error) {
+	t := &table{columns: make([]*column, len(columns))}
+	for i, c := range columns {
+		var err error
+		if t.columns[i], err = newColumn(c); err != nil {
+			return nil, err
+		}
+		t.rowSize += t.columns[i].size
+	}
+	return t, nil
+}
+
+// newEnvTable returns the test table defined by the command-line flags.
+func newEnvTable() (*table, error) {
+	columns, err := env.TableColumns()
+	if err != nil {
+		return nil, err
+	}
+	return newTable(columns)
+}
+
+// columnDefs returns the column definitions used in the create table statement.
+func (t *table) columnDefs() string {
+	s := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		s[i] = driver.Identifier(c.Name).String() + " " + c.Type
+	}
+	return strings.Join(s, ", ")
+}
+
+// projection returns the table columns with the given names (case insensitive) or all table columns if names is empty.
+func (t *table) projection(names []string) ([]*column, error) {
+	if len(names) == 0 {
+		return t.columns, nil
+	}
+	columns := make([]*column, len(names))
+	for i, name := range names {
+		for _, c := range t.columns {
+			if strings.EqualFold(c.Name, name) {
+				columns[i] = c
+				break
+			}
+		}
+		if columns[i] == nil {
+			return nil, fmt.Errorf("unknown column %s", name)
+		}
+	}
+	return columns, nil
+}
+
+// keyColumnDefs returns the column definitions including the primary key constraint on the key column.
+func (t *table) keyColumnDefs() string {
+	return fmt.Sprintf("%s, primary key (%s)", t.columnDefs(), driver.Identifier(t.key().Name))
+}
+
+// key returns the key column of the table (first column).
+// The key column is used to identify the rows by select and DML tests
+// and is expected to contain the row index (seq generator).
+func (t *table) key() *column { return t.columns[0] }
+
+// checkKey returns an error if the key column does not contain the row index (numeric column with seq generator).
+func (t *table) checkKey() error {
+	k := t.key()
+	numeric := k.kind == kindInt || k.kind == kindFloat || k.kind == kindDecimal
+	if _, ok := k.gen.(seqGenerator); !ok || !numeric {
+		return fmt.Errorf("key column %s: numeric column with %s generator expected", k.Name, genSeq)
+	}
+	return nil
+}
+
+// numColumn returns the number of table columns.
+func (t *table) numColumn() int { return len(t.columns) }
+
+// Row implements the RowGenerator interface.
+func (t *table) Row(rnd *rand.Rand, idx int) []interface{} {
+	row := make([]interface{}, len(t.columns))
+	for i, c := range t.columns {
+		row[i] = c.gen.Value(rnd, idx)
+	}
+	return row
+}
+
+// rows returns the size table rows of batch i generated with seed.
+func (t *table) rows(seed int64, i, size int) [][]interface{} {
+	rnd := batchRand(seed, i)
+	rows := make([][]interface{}, size)
+	for j := 0; j < size; j++ {
+		rows[j] = t.Row(rnd, i*size+j)
+	}
+	return rows
+}
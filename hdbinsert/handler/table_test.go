@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+)
+
+func TestTable(t *testing.T) {
+	columns := []env.Column{
+		{Name: "ID", Type: "INTEGER", Generator: "seq"},
+		{Name: "Name", Type: "NVARCHAR(20)", Generator: "string(8)"},
+		{Name: "AMOUNT", Type: "DECIMAL(18,2)", Generator: "uniform(0,1000)"},
+		{Name: "TS", Type: "TIMESTAMP"},
+		{Name: "DATA", Type: "VARBINARY(16)"},
+	}
+
+	tab, err := newTable(columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tab.numColumn() != len(columns) {
+		t.Fatalf("number of columns %d - expected %d", tab.numColumn(), len(columns))
+	}
+	if rowSize := 4 + 8 + 16 + 8 + 16; tab.rowSize != rowSize {
+		t.Fatalf("row size %d - expected %d", tab.rowSize, rowSize)
+	}
+	if defs := `ID INTEGER, "Name" NVARCHAR(20), AMOUNT DECIMAL(18,2), TS TIMESTAMP, DATA VARBINARY(16)`; tab.columnDefs() != defs {
+		t.Fatalf("column definitions %s - expected %s", tab.columnDefs(), defs)
+	}
+	if defs := `ID INTEGER, "Name" NVARCHAR(20), AMOUNT DECIMAL(18,2), TS TIMESTAMP, DATA VARBINARY(16), primary key (ID)`; tab.keyColumnDefs() != defs {
+		t.Fatalf("key column definitions %s - expected %s", tab.keyColumnDefs(), defs)
+	}
+
+	row := tab.Row(batchRand(1, 0), 42)
+	if v, ok := row[0].(int64); !ok || v != 42 {
+		t.Fatalf("invalid ID value %v", row[0])
+	}
+	if v, ok := row[1].(string); !ok || len(v) != 8 {
+		t.Fatalf("invalid NAME value %v", row[1])
+	}
+	if _, ok := row[2].(*big.Rat); !ok {
+		t.Fatalf("invalid AMOUNT value %v", row[2])
+	}
+	if _, ok := row[3].(time.Time); !ok {
+		t.Fatalf("invalid TS value %v", row[3])
+	}
+	if v, ok := row[4].([]byte); !ok || len(v) != 16 {
+		t.Fatalf("invalid DATA value %v", row[4])
+	}
+}
+
+func TestDecimalScale(t *testing.T) {
+	for _, test := range []struct {
+		typ   string
+		scale int
+	}{
+		{"DECIMAL", defDecimalScale},
+		{"DECIMAL(10)", 0},
+		{"DECIMAL(18, 2)", 2},
+		{"DECIMAL(38, 18)", 18},
+	} {
+		c, err := newColumn(env.Column{Name: "AMOUNT", Type: test.typ})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.length != test.scale {
+			t.Fatalf("%s: scale %d - expected %d", test.typ, c.length, test.scale)
+		}
+	}
+}
+
+func TestTableSeed(t *testing.T) {
+	columns := []env.Column{
+		{Name: "ID", Type: "INTEGER", Generator: "seq"},
+		{Name: "VALUE", Type: "DOUBLE", Generator: "normal(100,10)"},
+		{Name: "NAME", Type: "NVARCHAR(20)", Generator: "null(0.5,string(8))"},
+		{Name: "FLAG", Type: "BOOLEAN", Generator: "const(true)"},
+		{Name: "TS", Type: "TIMESTAMP", Generator: "timestamp(2021-06-01T00:00:00Z,1ms)"},
+	}
+
+	tab, err := newTable(columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same seed - same rows
+	if r1, r2 := tab.rows(42, 3, 100), tab.rows(42, 3, 100); !reflect.DeepEqual(r1, r2) {
+		t.Fatal("different rows generated for same seed")
+	}
+	// different seed - different rows
+	if r1, r2 := tab.rows(42, 3, 100), tab.rows(43, 3, 100); reflect.DeepEqual(r1, r2) {
+		t.Fatal("same rows generated for different seeds")
+	}
+
+	// seed S batch i+1 and seed S+1 batch i - different random values
+	for i := 0; i < 10; i++ {
+		if v1, v2 := batchRand(42, i+1).Int63(), batchRand(43, i).Int63(); v1 == v2 {
+			t.Fatalf("batch %d: same random values for shifted seeds", i)
+		}
+	}
+
+	row := tab.rows(42, 3, 100)[1]
+	if v := row[0].(int64); v != 301 {
+		t.Fatalf("ID %d - expected %d", v, 301)
+	}
+	if v := row[4].(time.Time); !v.Equal(time.Date(2021, 6, 1, 0, 0, 0, 301*int(time.Millisecond), time.UTC)) {
+		t.Fatalf("invalid TS value %s", v)
+	}
+}
+
+func TestTableKey(t *testing.T) {
+	for _, test := range []struct {
+		key env.Column
+		err bool
+	}{
+		{env.Column{Name: "ID", Type: "INTEGER", Generator: "seq"}, false},
+		{env.Column{Name: "ID", Type: "BIGINT"}, false}, // default generator seq
+		{env.Column{Name: "ID", Type: "INTEGER", Generator: "uniform(0,100)"}, true},
+		{env.Column{Name: "ID", Type: "NVARCHAR(20)", Generator: "seq"}, true},
+		{env.Column{Name: "TS", Type: "TIMESTAMP"}, true},
+		{env.Column{Name: "ID", Type: "DECIMAL(10)", Generator: "seq"}, false},
+		{env.Column{Name: "FLAG", Type: "BOOLEAN", Generator: "seq"}, true},
+		{env.Column{Name: "DATA", Type: "VARBINARY(16)"}, true},
+	} {
+		tab, err := newTable([]env.Column{test.key, {Name: "VALUE", Type: "DOUBLE"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tab.checkKey(); (err != nil) != test.err {
+			t.Fatalf("%s: key error %v", test.key, err)
+		}
+	}
+}
+
+func TestTableInvalid(t *testing.T) {
+	for _, c := range []env.Column{
+		{Name: "ID", Type: "UNKNOWN"},
+		{Name: "ID", Type: "INTEGER", Generator: "string(10)"},
+		{Name: "ID", Type: "INTEGER", Generator: "uniform(1)"},
+		{Name: "TS", Type: "TIMESTAMP", Generator: "seq"},
+		{Name: "NAME", Type: "NVARCHAR(20)", Generator: "null(2,string(8))"},
+		{Name: "NAME", Type: "NVARCHAR(20)", Generator: "null(0.1,string(8)"},
+		{Name: "NAME", Type: "VARCHAR(-5)"},
+		{Name: "DATA", Type: "VARBINARY(0)"},
+		{Name: "AMOUNT", Type: "DECIMAL(38,19)"},
+		{Name: "AMOUNT", Type: "DECIMAL(18,-1)"},
+		{Name: "NAME", Type: "NVARCHAR(20)", Generator: "string(-1)"},
+		{Name: "DATA", Type: "VARBINARY(20)", Generator: "null(0.1,bytes(-1))"},
+	} {
+		if _, err := newTable([]env.Column{c}); err == nil {
+			t.Fatalf("column %s: error expected", c)
+		}
+	}
+}
+
+func TestTableProjection(t *testing.T) {
+	tab, err := newTable([]env.Column{
+		{Name: "ID", Type: "INTEGER"},
+		{Name: "VALUE", Type: "DOUBLE"},
+		{Name: "NAME", Type: "NVARCHAR(20)"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, err := tab.projection(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != tab.numColumn() {
+		t.Fatalf("number of columns %d - expected %d", len(columns), tab.numColumn())
+	}
+
+	columns, err = tab.projection([]string{"name", "ID"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 2 || columns[0].Name != "NAME" || columns[1].Name != "ID" {
+		t.Fatalf("invalid projection %v", columns)
+	}
+
+	if _, err := tab.projection([]string{"UNKNOWN"}); err == nil {
+		t.Fatal("error expected")
+	}
+}
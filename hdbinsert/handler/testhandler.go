@@ -8,23 +8,27 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand"
 	"net/http"
+	"path"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/SAP/go-hdb/driver"
 	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
 )
 
-func getBulkInsertQuery(schemaName, tableName string) string {
-	return fmt.Sprintf("bulk insert into %s.%s values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", driver.Identifier(schemaName), driver.Identifier(tableName))
+// placeholders returns the statement parameter placeholders for numColumn columns.
+func placeholders(numColumn int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", numColumn), ", ")
 }
 
-func getInsertQuery(schemaName, tableName string) string {
-	return fmt.Sprintf("insert into %s.%s values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", driver.Identifier(schemaName), driver.Identifier(tableName))
+func getInsertQuery(schemaName, tableName string, numColumn int) string {
+	return fmt.Sprintf("insert into %s.%s values (%s)", driver.Identifier(schemaName), driver.Identifier(tableName), placeholders(numColumn))
 }
 
 // Test URL paths.
@@ -33,249 +37,936 @@ const (
 	TestManySeq = "/test/ManySeq"
 	TestBulkPar = "/test/BulkPar"
 	TestManyPar = "/test/ManyPar"
+	TestLobSeq  = "/test/LobSeq"
+	TestLobPar  = "/test/LobPar"
+
+	TestSelectSeq = "/test/SelectSeq"
+	TestSelectPar = "/test/SelectPar"
+
+	TestUpsertBulkSeq = "/test/UpsertBulkSeq"
+	TestUpsertManySeq = "/test/UpsertManySeq"
+	TestUpsertBulkPar = "/test/UpsertBulkPar"
+	TestUpsertManyPar = "/test/UpsertManyPar"
+	TestUpdateBulkSeq = "/test/UpdateBulkSeq"
+	TestUpdateManySeq = "/test/UpdateManySeq"
+	TestUpdateBulkPar = "/test/UpdateBulkPar"
+	TestUpdateManyPar = "/test/UpdateManyPar"
+	TestDeleteBulkSeq = "/test/DeleteBulkSeq"
+	TestDeleteManySeq = "/test/DeleteManySeq"
+	TestDeleteBulkPar = "/test/DeleteBulkPar"
+	TestDeleteManyPar = "/test/DeleteManyPar"
+
+	TestSoakBulk = "/test/SoakBulk"
+	TestSoakMany = "/test/SoakMany"
 )
 
+// testOps maps the tests to the database operation displayed in the test result (default insert).
+var testOps = map[string]string{
+	TestSelectSeq: "select",
+	TestSelectPar: "select",
+
+	TestUpsertBulkSeq: "upsert",
+	TestUpsertManySeq: "upsert",
+	TestUpsertBulkPar: "upsert",
+	TestUpsertManyPar: "upsert",
+	TestUpdateBulkSeq: "update",
+	TestUpdateManySeq: "update",
+	TestUpdateBulkPar: "update",
+	TestUpdateManyPar: "update",
+	TestDeleteBulkSeq: "delete",
+	TestDeleteManySeq: "delete",
+	TestDeleteBulkPar: "delete",
+	TestDeleteManyPar: "delete",
+}
+
+// testPath returns the test URL path of the test name (e.g. BulkSeq or /test/BulkSeq).
+func testPath(name string) string { return "/test/" + path.Base(name) }
+
+func testOp(test string) string {
+	if op, ok := testOps[test]; ok {
+		return op
+	}
+	return "insert"
+}
+
 // TestResult is the structure used to provide the JSON based test result response.
 type TestResult struct {
-	Test       string
-	Seconds    float64
-	BatchCount int
-	BatchSize  int
-	BulkSize   int
-	Duration   time.Duration
-	Error      string
+	RunID          int64 // id of the stored result (see resultFile command-line flag)
+	Label          string
+	Test           string
+	DriverVersion  string
+	HDBVersion     string
+	Seconds        float64
+	BatchCount     int
+	BatchSize      int
+	BulkSize       int
+	BufferSize     int
+	FetchSize      int
+	Drop           bool          // drop and re-create the test table
+	Separate       bool          // parallel tests: use a separate table for each worker
+	Wait           time.Duration // wait time before starting the test
+	TLS            bool          `json:",omitempty"` // TLS database connections
+	Workers        int           `json:",omitempty"` // parallel tests: number of workers (connections)
+	Rate           int           `json:",omitempty"` // soak tests: target rate in rows per second
+	Seed           int64
+	NumRow         int64 // number of successfully processed rows
+	NumFailedRow   int64
+	Duration       time.Duration
+	RowsPerSecond  float64
+	BytesPerSecond float64
+	MBPerSecond    float64
+	TimeToFirstRow time.Duration  // select tests: duration until the first row was fetched
+	Latency        *LatencyResult // statement execution latencies
+	Windows        []*SoakWindow  `json:",omitempty"` // soak tests: throughput per time window
+	Stats          *RunStats      `json:",omitempty"` // duration statistics of repeated test runs
+	Errors         []*WorkerError `json:",omitempty"` // parallel test worker errors (max. 100)
+	Error          string
 }
 
 func (r *TestResult) String() string {
 	if r.Error != "" {
 		return r.Error
 	}
-	return fmt.Sprintf("%s: insert of %d rows in %f seconds (batchCount %d batchSize %d bulkSize %d)", r.Test, r.BatchCount*r.BatchSize, r.Duration.Seconds(), r.BatchCount, r.BatchSize, r.BulkSize)
+	s := fmt.Sprintf("%s: %s of %d rows in %f seconds (batchCount %d batchSize %d bulkSize %d bufferSize %d fetchSize %d)", r.Test, testOp(r.Test), r.NumRow, r.Duration.Seconds(), r.BatchCount, r.BatchSize, r.BulkSize, r.BufferSize, r.FetchSize)
+	if r.Workers > 0 {
+		s = fmt.Sprintf("%s - %d workers", s, r.Workers)
+	}
+	if r.TLS {
+		s += " - TLS"
+	}
+	if r.TimeToFirstRow > 0 {
+		s = fmt.Sprintf("%s - first row after %s", s, r.TimeToFirstRow)
+	}
+	if r.Latency != nil {
+		s = fmt.Sprintf("%s - %.0f rows/s - exec latency p50 %s p90 %s p99 %s p999 %s max %s", s, r.RowsPerSecond, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.P999, r.Latency.Max)
+	}
+	if r.Stats != nil {
+		s = fmt.Sprintf("%s - %s", s, r.Stats)
+	}
+	return s
+}
+
+// testPrm contains the parameters of a test run.
+type testPrm struct {
+	batchCount, batchSize int
+	drop, separate        bool
+	wait                  time.Duration
+	seed                  int64 // seed of the row generator random number source
+	lobSize               int   // size of large objects in bytes (LOB tests)
+	fetchSize             int
+	bufferSize            int
+	columns               []string // projected columns (select tests) - all columns if empty
+	label                 string
+	workers               int           // number of workers of parallel tests - one worker per batch if zero
+	duration              time.Duration // duration of soak tests
+	rate                  int           // target rate of soak tests in rows per second - unlimited if zero
+	window                time.Duration // soak test throughput reporting window
+	repeat, warmup        int           // number of measured and discarded warmup runs
+	bulkSize              int           // batchSize if zero - set to the connector bulk size by TestHandler.run
+}
+
+type testFunc func(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm) (time.Duration, error)
+
+// monitor tracks the progress and the statement execution latencies of a running test.
+type monitor struct {
+	numRow   int64 // accessed atomically
+	numByte  int64 // accessed atomically
+	firstRow int64 // time to first fetched row in nanoseconds (select tests) - accessed atomically
+	rowSize  int   // payload bytes per row - set by TestHandler.run or by the test function
+	latency  *histogram
+	metrics  *testMetrics // set by TestHandler.run
+	workers  int          // number of workers of parallel tests
+	window   atomic.Value // *histogram of the current soak test window
+	windows  []*SoakWindow
+
+	numFailedRow int64 // accessed atomically
+
+	// worker errors and progress
+	mu       sync.Mutex
+	numError int
+	errs     []*WorkerError
+	progress []*workerProgress
+}
+
+func newMonitor() *monitor { return &monitor{latency: newHistogram()} }
+
+// exec records a successful statement execution of duration d inserting numRow rows.
+func (m *monitor) exec(d time.Duration, numRow int) {
+	m.latency.record(d)
+	if w, ok := m.window.Load().(*histogram); ok {
+		w.record(d)
+	}
+	atomic.AddInt64(&m.numRow, int64(numRow))
+	atomic.AddInt64(&m.numByte, int64(numRow)*int64(m.rowSize))
+	if m.metrics != nil {
+		m.metrics.exec(d, numRow)
+	}
+}
+
+// buffered records numRow rows buffered by the driver without round-trip to the database.
+// In contrast to exec no latency sample is recorded.
+func (m *monitor) buffered(numRow int) {
+	atomic.AddInt64(&m.numRow, int64(numRow))
+	atomic.AddInt64(&m.numByte, int64(numRow)*int64(m.rowSize))
+	if m.metrics != nil {
+		m.metrics.addRows(numRow)
+	}
+}
+
+// startWorker records the start of a test worker. The returned function needs to be called when the worker is finished.
+func (m *monitor) startWorker() func() {
+	if m.metrics == nil {
+		return func() {}
+	}
+	m.metrics.addWorker(1)
+	return func() { m.metrics.addWorker(-1) }
+}
+
+// rows returns the number of rows inserted so far.
+func (m *monitor) rows() int64 { return atomic.LoadInt64(&m.numRow) }
+
+// bytes returns the number of payload bytes inserted so far.
+func (m *monitor) bytes() int64 { return atomic.LoadInt64(&m.numByte) }
+
+// fetched records the duration d from test start until a first row was fetched
+// by a worker. Only the earliest duration is kept.
+func (m *monitor) fetched(d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&m.firstRow)
+		if old != 0 && old <= int64(d) {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&m.firstRow, old, int64(d)) {
+			return
+		}
+	}
 }
 
-type testFunc func(db *sql.DB, batchCount, batchSize int, drop, separate bool, wait time.Duration) (time.Duration, error)
+// timeToFirstRow returns the duration from test start until the first row was fetched.
+func (m *monitor) timeToFirstRow() time.Duration { return time.Duration(atomic.LoadInt64(&m.firstRow)) }
+
+// reset clears the monitor for the next run of a repeated test.
+func (m *monitor) reset() {
+	atomic.StoreInt64(&m.numRow, 0)
+	atomic.StoreInt64(&m.numByte, 0)
+	atomic.StoreInt64(&m.firstRow, 0)
+	atomic.StoreInt64(&m.numFailedRow, 0)
+	m.latency = newHistogram()
+	m.workers, m.windows = 0, nil
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.numError, m.errs, m.progress = 0, nil, nil
+}
 
 // TestHandler implements the http.Handler interface for the tests.
 type TestHandler struct {
 	log        logFunc
-	dsn        string
+	connConfig *connectorConfig
 	schemaName string
 	tableName  string
+	table      *table
+	lobType    string
 	testFuncs  map[string]testFunc
+	jobs       *jobs
+	metrics    *metrics
+	store      *resultStore // nil if results are not persisted
+
+	// running tests
+	mu     sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+	ctx    context.Context // cancelled to abort all running tests
+	cancel context.CancelFunc
 }
 
 // NewTestHandler returns a new TestHandler instance.
 func NewTestHandler(log logFunc) (*TestHandler, error) {
-	h := &TestHandler{log: log, dsn: env.DSN(), schemaName: env.SchemaName(), tableName: env.TableName()}
+	table, err := newEnvTable()
+	if err != nil {
+		return nil, err
+	}
+	lobType, err := checkLobType(env.LobType())
+	if err != nil {
+		return nil, err
+	}
+	connConfig, err := newEnvConnectorConfig()
+	if err != nil {
+		return nil, err
+	}
+	h := &TestHandler{log: log, connConfig: connConfig, schemaName: env.SchemaName(), tableName: env.TableName(), table: table, lobType: lobType, jobs: newJobs(), metrics: newMetrics()}
+	if fn := env.ResultFile(); fn != "" {
+		if h.store, err = newResultStore(fn, log); err != nil {
+			return nil, err
+		}
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	bulkSeq, manySeq, bulkPar, manyPar := dmlFunc(h.bulkSeq), dmlFunc(h.manySeq), dmlFunc(h.bulkPar), dmlFunc(h.manyPar)
 	h.testFuncs = map[string]testFunc{
-		TestBulkSeq: h.bulkSeq,
-		TestManySeq: h.manySeq,
-		TestBulkPar: h.bulkPar,
-		TestManyPar: h.manyPar,
+		TestBulkSeq: bulkSeq.with(dmlInsert),
+		TestManySeq: manySeq.with(dmlInsert),
+		TestBulkPar: bulkPar.with(dmlInsert),
+		TestManyPar: manyPar.with(dmlInsert),
+		TestLobSeq:  h.lobSeq,
+		TestLobPar:  h.lobPar,
+
+		TestSelectSeq: h.selectSeq,
+		TestSelectPar: h.selectPar,
+
+		TestUpsertBulkSeq: bulkSeq.with(dmlUpsert),
+		TestUpsertManySeq: manySeq.with(dmlUpsert),
+		TestUpsertBulkPar: bulkPar.with(dmlUpsert),
+		TestUpsertManyPar: manyPar.with(dmlUpsert),
+		TestUpdateBulkSeq: bulkSeq.with(dmlUpdate),
+		TestUpdateManySeq: manySeq.with(dmlUpdate),
+		TestUpdateBulkPar: bulkPar.with(dmlUpdate),
+		TestUpdateManyPar: manyPar.with(dmlUpdate),
+		TestDeleteBulkSeq: bulkSeq.with(dmlDelete),
+		TestDeleteManySeq: manySeq.with(dmlDelete),
+		TestDeleteBulkPar: bulkPar.with(dmlDelete),
+		TestDeleteManyPar: manyPar.with(dmlDelete),
+
+		TestSoakBulk: h.soakBulk,
+		TestSoakMany: h.soakMany,
 	}
 	return h, nil
 }
 
-func (h *TestHandler) tests() []string {
+// IsSeqTest returns true if test is executed sequentially, ignoring the number of workers.
+func IsSeqTest(test string) bool { return strings.HasSuffix(test, "Seq") }
+
+// testGroup is a group of tests displayed together on the index page.
+type testGroup struct {
+	Name  string
+	Tests []testCol
+}
+
+// testCol is a test displayed in a column on the index page.
+type testCol struct {
+	Header string
+	Test   string
+}
+
+// Seq reports whether the test is executed sequentially, ignoring the number of workers.
+func (c testCol) Seq() bool { return IsSeqTest(c.Test) }
+
+func (h *TestHandler) testGroups() []testGroup {
 	// need correct sort order
-	return []string{TestBulkSeq, TestManySeq, TestBulkPar, TestManyPar}
+	return []testGroup{
+		{Name: "Sequential", Tests: []testCol{{"bulk", TestBulkSeq}, {"many", TestManySeq}}},
+		{Name: "Parallel", Tests: []testCol{{"bulk", TestBulkPar}, {"many", TestManyPar}}},
+		{Name: "LOB", Tests: []testCol{{"seq", TestLobSeq}, {"par", TestLobPar}}},
+		{Name: "Select", Tests: []testCol{{"seq", TestSelectSeq}, {"par", TestSelectPar}}},
+		{Name: "Upsert", Tests: []testCol{{"bulk seq", TestUpsertBulkSeq}, {"many seq", TestUpsertManySeq}, {"bulk par", TestUpsertBulkPar}, {"many par", TestUpsertManyPar}}},
+		{Name: "Update", Tests: []testCol{{"bulk seq", TestUpdateBulkSeq}, {"many seq", TestUpdateManySeq}, {"bulk par", TestUpdateBulkPar}, {"many par", TestUpdateManyPar}}},
+		{Name: "Delete", Tests: []testCol{{"bulk seq", TestDeleteBulkSeq}, {"many seq", TestDeleteManySeq}, {"bulk par", TestDeleteBulkPar}, {"many par", TestDeleteManyPar}}},
+		{Name: "Soak", Tests: []testCol{{"bulk", TestSoakBulk}, {"many", TestSoakMany}}},
+	}
+}
+
+func (h *TestHandler) tests() []string {
+	tests := []string{}
+	for _, g := range h.testGroups() {
+		for _, t := range g.Tests {
+			tests = append(tests, t.Test)
+		}
+	}
+	return tests
 }
 
+// Tests returns the URL paths of all tests.
+func (h *TestHandler) Tests() []string { return h.tests() }
+
 const (
 	defBatchCount = 10
 	defBatchSize  = 10000
 )
 
-func (h *TestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Try to get a comparable environment for each run
-	// by clearing garbage from previous runs.
-	runtime.GC()
+var errShutdown = errors.New("test handler is shutting down")
 
+// startRun registers a running test and returns its context derived from parent.
+// The context is cancelled after timeout (if timeout > 0) or when the running tests
+// are aborted on shutdown. The returned function needs to be called when the test is finished.
+func (h *TestHandler) startRun(parent context.Context, timeout time.Duration) (context.Context, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, errShutdown
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	cancelTimeout := func() {}
+	if timeout > 0 {
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-h.ctx.Done():
+			cancel()
+		case <-done:
+		}
+	}()
+
+	h.wg.Add(1)
+	return ctx, func() {
+		close(done)
+		cancelTimeout()
+		cancel()
+		h.wg.Done()
+	}, nil
+}
+
+// Shutdown stops accepting new tests and waits for the running tests to be finished.
+// If ctx is done before, all running tests are aborted and the context error is returned.
+func (h *TestHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		h.cancel()
+		return nil
+	case <-ctx.Done():
+		h.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (h *TestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	q := newURLQuery(r)
 
-	batchCount := q.getInt(urlQueryBatchCount, defBatchCount)
-	batchSize := q.getInt(urlQueryBatchSize, defBatchSize)
+	// Stream test progress as Server-Sent Events followed by the test result.
+	// Parameter errors are streamed as result event as well, so that they are received by event stream clients.
+	streaming := q.getBool(urlQueryStream, false)
 
-	drop := env.Drop()
-	separate := env.Separate()
-	wait := time.Duration(env.Wait()) * time.Second
+	prm, err := newTestPrm(q)
+	var timeout time.Duration
+	if err == nil {
+		timeout, err = q.getValidDuration(urlQueryTimeout, 0)
+	}
 
 	test := r.URL.Path
 
-	result := &TestResult{Test: test, BatchCount: batchCount, BatchSize: batchSize}
+	e := json.NewEncoder(w)
 
-	defer func() {
+	format := formatJSON
+	if err == nil {
+		format, err = resultFormat(r, q)
+	}
+	if err != nil {
+		result := &TestResult{Test: test, BatchCount: prm.batchCount, BatchSize: prm.batchSize, Error: err.Error()}
+		h.log("%s", result)
+		if streaming {
+			streamResult(w, result)
+			return
+		}
+		e.Encode(result) // ignore error
+		return
+	}
+
+	if streaming {
+		h.stream(w, r, test, prm, timeout, q.getDuration(urlQueryInterval, defStreamInterval))
+		return
+	}
+
+	// Run test asynchronously and return job instead of test result.
+	// The job context is independent of the request context.
+	if q.getBool(urlQueryAsync, false) {
+		ctx, end, err := h.startRun(context.Background(), timeout)
+		if err != nil {
+			result := &JobResult{Test: test, BatchCount: prm.batchCount, BatchSize: prm.batchSize, Error: err.Error()}
+			h.log("%s", result)
+			e.Encode(result) // ignore error
+			return
+		}
+		j := h.jobs.start(ctx, test, prm.batchCount, prm.batchSize, func(ctx context.Context, m *monitor) *TestResult {
+			defer end()
+			result := h.run(ctx, m, test, prm)
+			h.log("%s", result)
+			return result
+		})
+		result := j.result()
 		h.log("%s", result)
-		e := json.NewEncoder(w)
 		e.Encode(result) // ignore error
+		return
+	}
+
+	var result *TestResult
+	ctx, end, err := h.startRun(r.Context(), timeout)
+	if err != nil {
+		result = &TestResult{Test: test, BatchCount: prm.batchCount, BatchSize: prm.batchSize, Error: err.Error()}
+	} else {
+		result = h.run(ctx, newMonitor(), test, prm)
+		end()
+	}
+	h.log("%s", result)
+	if format == formatJSON {
+		e.Encode(result) // ignore error
+		return
+	}
+	writeResults(w, format, []*TestResult{result}) // ignore error
+}
+
+// Run executes test with batchCount, batchSize, the number of workers of parallel tests and the command-line
+// flags as test parameters without HTTP server. The test is aborted when ctx is done.
+func (h *TestHandler) Run(ctx context.Context, test string, batchCount, batchSize, workers int) *TestResult {
+	prm, err := newTestPrm(&urlQuery{}) // command-line flags only
+	if err != nil {
+		return &TestResult{Test: test, BatchCount: batchCount, BatchSize: batchSize, Error: err.Error()}
+	}
+	prm.batchCount, prm.batchSize, prm.workers = batchCount, batchSize, workers
+
+	ctx, end, err := h.startRun(ctx, 0)
+	if err != nil {
+		return &TestResult{Test: test, BatchCount: batchCount, BatchSize: batchSize, Error: err.Error()}
+	}
+	defer end()
+
+	result := h.run(ctx, newMonitor(), test, prm)
+	h.log("%s", result)
+	return result
+}
+
+// Compare compares the stored results of the test runs labeled base and candidate (see label command-line flag).
+// Tests with a candidate median duration exceeding the base median duration by more than threshold percent
+// and a statistically significant difference are flagged as regression.
+func (h *TestHandler) Compare(base, candidate string, threshold float64) *Comparison {
+	if h.store == nil {
+		return &Comparison{Base: base, Candidate: candidate, Threshold: threshold, Error: errNoResultStore.Error()}
+	}
+	return h.store.compareRuns(base, candidate, threshold)
+}
+
+// newTestPrm returns the test parameters defined by the URL query and the command-line flags.
+// In case of an invalid drop, separate, wait, bufferSize, bulkSize or lobSize parameter the test parameters are returned together with an error.
+func newTestPrm(q *urlQuery) (*testPrm, error) {
+	prm := &testPrm{
+		batchCount: q.getInt(urlQueryBatchCount, defBatchCount),
+		batchSize:  q.getInt(urlQueryBatchSize, defBatchSize),
+		seed:       q.getInt64(urlQuerySeed, env.Seed()),
+		fetchSize:  q.getInt(urlQueryFetchSize, env.FetchSize()),
+		columns:    q.getStrings(urlQueryColumns),
+		label:      q.getString(urlQueryLabel, env.Label()),
+		workers:    q.getInt(urlQueryWorkers, 0),
+		duration:   q.getDuration(urlQueryDuration, time.Duration(env.SoakDuration())*time.Second),
+		rate:       q.getInt(urlQueryRate, env.SoakRate()),
+		window:     q.getDuration(urlQueryWindow, time.Duration(env.SoakWindow())*time.Second),
+		repeat:     q.getInt(urlQueryRepeat, 1),
+		warmup:     q.getInt(urlQueryWarmup, 0),
+	}
+
+	var err error
+	if prm.drop, err = q.getValidBool(urlQueryDrop, env.Drop()); err != nil {
+		return prm, err
+	}
+	if prm.separate, err = q.getValidBool(urlQuerySeparate, env.Separate()); err != nil {
+		return prm, err
+	}
+	wait, err := q.getValidInt(urlQueryWait, env.Wait())
+	if err != nil {
+		return prm, err
+	}
+	if wait < 0 {
+		return prm, fmt.Errorf("invalid wait %d seconds", wait)
+	}
+	prm.wait = time.Duration(wait) * time.Second
+	if prm.bufferSize, err = q.getValidInt(urlQueryBufferSize, env.BufferSize()); err != nil {
+		return prm, err
+	}
+	if prm.bufferSize <= 0 {
+		return prm, fmt.Errorf("invalid buffer size %d", prm.bufferSize)
+	}
+	if prm.bulkSize, err = q.getValidInt(urlQueryBulkSize, 0); err != nil {
+		return prm, err
+	}
+	if prm.bulkSize < 0 {
+		return prm, fmt.Errorf("invalid bulk size %d", prm.bulkSize)
+	}
+	if prm.lobSize, err = q.getValidInt(urlQueryLobSize, env.LobSize()); err != nil {
+		return prm, err
+	}
+	if prm.lobSize < 0 {
+		return prm, fmt.Errorf("invalid lob size %d", prm.lobSize)
+	}
+	return prm, nil
+}
+
+// run executes the warmup and the measured runs of a test and returns the test result of the last run.
+// In case of warmup or repeated runs the test result contains the duration statistics of the measured runs.
+func (h *TestHandler) run(ctx context.Context, m *monitor, test string, prm *testPrm) *TestResult {
+	if prm.warmup <= 0 && prm.repeat <= 1 {
+		return h.runOnce(ctx, m, test, prm, true)
+	}
+
+	for i := 0; i < prm.warmup; i++ {
+		result := h.runOnce(ctx, m, test, prm, false)
+		m.reset()
+		if result.Error != "" {
+			result.Error = fmt.Sprintf("warmup run %d: %s", i+1, result.Error)
+			return result
+		}
+	}
+
+	var result *TestResult
+	seconds := []float64{}
+	for i := 0; i < prm.repeat || i == 0; i++ {
+		if i > 0 {
+			m.reset()
+		}
+		if result = h.runOnce(ctx, m, test, prm, true); result.Error != "" {
+			break
+		}
+		seconds = append(seconds, result.Seconds)
+	}
+	result.Stats = newRunStats(prm.warmup, seconds)
+	return result
+}
+
+// runOnce executes a test and returns the test result. The test result is persisted if store is true.
+func (h *TestHandler) runOnce(ctx context.Context, m *monitor, test string, prm *testPrm, store bool) *TestResult {
+	// Try to get a comparable environment for each run
+	// by clearing garbage from previous runs.
+	runtime.GC()
+
+	// Use random seed if not defined.
+	if prm.seed == 0 {
+		prm.seed = time.Now().UnixNano()
+	}
+
+	result := &TestResult{
+		Label:         prm.label,
+		Test:          test,
+		DriverVersion: driver.DriverVersion,
+		BatchCount:    prm.batchCount,
+		BatchSize:     prm.batchSize,
+		Drop:          prm.drop,
+		Separate:      prm.separate,
+		Wait:          prm.wait,
+		Seed:          prm.seed,
+	}
+
+	f, ok := h.testFuncs[test]
+	if !ok {
+		result.Error = fmt.Sprintf("Invalid test %s", test)
+		return result
+	}
+
+	// Persist result.
+	defer func() {
+		if h.store == nil || !store {
+			return
+		}
+		if _, err := h.store.add(result); err != nil {
+			h.log("store result of test %s: %s", test, err)
+		}
+	}()
+
+	m.metrics = h.metrics.test(test)
+	atomic.AddUint64(&m.metrics.numRun, 1)
+	defer func() {
+		if result.Error != "" {
+			atomic.AddUint64(&m.metrics.numError, 1)
+		}
 	}()
 
-	db, bulkSize, err := h.setup(batchSize)
+	db, connector, err := h.setup(prm)
 	if err != nil {
 		result.Error = err.Error()
-		return
+		return result
 	}
+	h.metrics.addDB(test, db)
 	defer h.teardown(db)
 
-	var d time.Duration
-
-	if f, ok := h.testFuncs[test]; ok {
-		d, err = f(db, batchCount, batchSize, drop, separate, wait)
-	} else {
-		err = fmt.Errorf("Invalid test %s", test)
+	if result.HDBVersion, err = hdbVersion(ctx, db); err != nil {
+		result.Error = err.Error()
+		return result
 	}
 
-	result.BulkSize = bulkSize
+	m.rowSize = h.table.rowSize
+	prm.bulkSize = connector.BulkSize()
+	d, err := f(ctx, m, db, prm)
+
+	result.BulkSize = connector.BulkSize()
+	result.BufferSize = connector.BufferSize()
+	result.TLS = connector.TLSConfig() != nil
+	result.FetchSize = connector.FetchSize()
+	result.Workers = m.workers
+	result.Windows = m.windows
+	if m.windows != nil {
+		result.Rate = prm.rate
+	}
+	result.NumRow = m.rows()
+	result.NumFailedRow = m.failedRows()
 	result.Duration = d
 	result.Seconds = d.Seconds()
+	if d > 0 {
+		result.RowsPerSecond = float64(m.rows()) / d.Seconds()
+		result.BytesPerSecond = float64(m.bytes()) / d.Seconds()
+		result.MBPerSecond = result.BytesPerSecond / 1e6
+	}
+	result.TimeToFirstRow = m.timeToFirstRow()
+	result.Latency = m.latency.result()
+	result.Errors = m.workerErrors()
 	if err != nil {
 		result.Error = err.Error()
 	}
+	return result
 }
 
-func (h *TestHandler) bulkSeq(db *sql.DB, batchCount, batchSize int, drop, separate bool, wait time.Duration) (time.Duration, error) {
-	numRow := batchCount * batchSize
+// sleep pauses the current goroutine for at least the duration d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	ensureTable(db, h.schemaName, h.tableName, drop)
-	if wait > 0 {
-		time.Sleep(wait)
+func (h *TestHandler) bulkSeq(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm, op dmlOp) (time.Duration, error) {
+	tableName := h.dmlTable(op, 0, false)
+	if err := h.prepareTable(ctx, db, prm, op, tableName, batchRange(prm.batchCount)...); err != nil {
+		return 0, err
+	}
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
 	}
 
-	conn, err := db.Conn(context.Background())
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		return 0, err
 	}
+	defer conn.Close()
 
-	stmt, err := conn.PrepareContext(context.Background(), getBulkInsertQuery(h.schemaName, h.tableName))
+	stmt, err := conn.PrepareContext(ctx, op.query(h.schemaName, tableName, h.table, true))
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
+	m.rowSize = op.rowSize(h.table)
+	defer m.startWorker()()
+	m.startWorkers(prm.batchCount)
+	defer m.workerFinished(0)
+
 	var d time.Duration
 
-	for i := 0; i < numRow; i++ {
-		row := randRow(i)
-		t := time.Now()
-		if _, err := stmt.Exec(row...); err != nil {
-			return d, err
+	// Rows are buffered by the driver and flushed when reaching the bulk size (or by the final stmt.Exec()),
+	// so that latencies are recorded for the flushing executions only.
+	numPending := 0
+	for i := 0; i < prm.batchCount; i++ {
+		rnd := batchRand(prm.seed, i)
+		for j := 0; j < prm.batchSize; j++ {
+			row := op.args(h.table.Row(rnd, i*prm.batchSize+j))
+			t := time.Now()
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				return d, err
+			}
+			e := time.Since(t)
+			d += e
+			if numPending++; numPending == prm.bulkSize {
+				m.exec(e, 1)
+				numPending = 0
+			} else {
+				m.buffered(1)
+			}
 		}
-		d += time.Since(t)
+		m.batchDone(0)
 	}
 
 	// Call final stmt.Exec().
 	t := time.Now()
-	if _, err := stmt.Exec(); err != nil {
+	if _, err := stmt.ExecContext(ctx); err != nil {
 		return d, err
 	}
-	d += time.Since(t)
+	e := time.Since(t)
+	d += e
+	if numPending != 0 { // nothing to flush otherwise
+		m.exec(e, 0)
+	}
 
 	return d, nil
 }
 
-func (h *TestHandler) manySeq(db *sql.DB, batchCount, batchSize int, drop, separate bool, wait time.Duration) (time.Duration, error) {
-	ensureTable(db, h.schemaName, h.tableName, drop)
-	if wait > 0 {
-		time.Sleep(wait)
+func (h *TestHandler) manySeq(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm, op dmlOp) (time.Duration, error) {
+	tableName := h.dmlTable(op, 0, false)
+	if err := h.prepareTable(ctx, db, prm, op, tableName, batchRange(prm.batchCount)...); err != nil {
+		return 0, err
+	}
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
 	}
 
-	conn, err := db.Conn(context.Background())
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		return 0, err
 	}
+	defer conn.Close()
 
-	stmt, err := conn.PrepareContext(context.Background(), getInsertQuery(h.schemaName, h.tableName))
+	stmt, err := conn.PrepareContext(ctx, op.query(h.schemaName, tableName, h.table, false))
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
+	m.rowSize = op.rowSize(h.table)
+	defer m.startWorker()()
+	m.startWorkers(prm.batchCount)
+	defer m.workerFinished(0)
+
 	var d time.Duration
 
-	for i := 0; i < batchCount; i++ {
-		rows := randRows(i, batchSize)
+	for i := 0; i < prm.batchCount; i++ {
+		rows := op.rows(h.table, prm.seed, i, prm.batchSize)
 		t := time.Now()
-		if _, err := stmt.Exec(rows); err != nil {
+		if _, err := stmt.ExecContext(ctx, rows); err != nil {
 			return d, err
 		}
-		d += time.Since(t)
+		e := time.Since(t)
+		d += e
+		m.exec(e, prm.batchSize)
+		m.batchDone(0)
 	}
 
 	return d, nil
 }
 
+// task is a parallel test worker executing its batches sequentially using an own database connection.
 type task struct {
-	conn *sql.Conn
-	stmt *sql.Stmt
-	rows [][]interface{}
-	err  error
+	conn    *sql.Conn
+	stmts   map[string]*sql.Stmt // prepared statements by query
+	batches []*taskBatch
 }
 
-func newTask(db *sql.DB, query string, i, size int) (*task, error) {
-	conn, err := db.Conn(context.Background())
-	if err != nil {
-		return nil, err
-	}
+// taskBatch is a batch executed by a task.
+type taskBatch struct {
+	stmt   *sql.Stmt
+	rows   [][]interface{}
+	table  string
+	offset int // index of the first batch row in the test rows
+}
 
-	stmt, err := conn.PrepareContext(context.Background(), query)
+func newTask(ctx context.Context, db *sql.DB) (*task, error) {
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return &task{conn: conn, stmts: map[string]*sql.Stmt{}}, nil
+}
 
-	return &task{conn: conn, stmt: stmt, rows: randRows(i, size)}, nil
+// addBatch adds a batch executing query for rows to the task.
+func (t *task) addBatch(ctx context.Context, query string, rows [][]interface{}, table string, offset int) error {
+	stmt, ok := t.stmts[query]
+	if !ok {
+		var err error
+		if stmt, err = t.conn.PrepareContext(ctx, query); err != nil {
+			return err
+		}
+		t.stmts[query] = stmt
+	}
+	t.batches = append(t.batches, &taskBatch{stmt: stmt, rows: rows, table: table, offset: offset})
+	return nil
 }
 
 func (t *task) close() {
-	t.stmt.Close()
+	for _, stmt := range t.stmts {
+		stmt.Close()
+	}
 	t.conn.Close()
 }
 
-func (h *TestHandler) createTasks(db *sql.DB, batchCount, batchSize int, bulk, drop, separate bool) ([]*task, error) {
-	tableName := h.tableName
+// numWorker returns the number of parallel test workers.
+func (prm *testPrm) numWorker() int {
+	if prm.workers <= 0 || prm.workers > prm.batchCount {
+		return prm.batchCount // one worker per batch
+	}
+	return prm.workers
+}
+
+// batchWorker returns the index of the worker executing batch i (round-robin).
+func (prm *testPrm) batchWorker(i int) int { return i % prm.numWorker() }
+
+// newTasks returns the parallel test workers.
+func newTasks(ctx context.Context, db *sql.DB, prm *testPrm) ([]*task, error) {
+	tasks := make([]*task, prm.numWorker())
+	for i := range tasks {
+		var err error
+		if tasks[i], err = newTask(ctx, db); err != nil {
+			closeTasks(tasks[:i])
+			return nil, err
+		}
+	}
+	return tasks, nil
+}
+
+// createTasks creates the parallel test workers and assigns the batches round-robin to the workers.
+func (h *TestHandler) createTasks(ctx context.Context, db *sql.DB, prm *testPrm, op dmlOp, bulk bool) ([]*task, error) {
+	tableName := h.dmlTable(op, 0, false)
 
 	// use same table for all tasks
-	if !separate {
-		if err := ensureTable(db, h.schemaName, tableName, drop); err != nil {
+	if !prm.separate {
+		if err := h.prepareTable(ctx, db, prm, op, tableName, batchRange(prm.batchCount)...); err != nil {
 			return nil, err
 		}
 	}
 
-	var err error
-	tasks := make([]*task, batchCount)
-	for i := 0; i < batchCount; i++ {
-		// use separate table for each task
-		if separate {
-			tableName = fmt.Sprintf("%s_%d", h.tableName, i)
-			if err := ensureTable(db, h.schemaName, tableName, drop); err != nil {
+	tasks, err := newTasks(ctx, db, prm)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < prm.batchCount; i++ {
+		// use separate table for each batch
+		if prm.separate {
+			tableName = h.dmlTable(op, i, true)
+			if err := h.prepareTable(ctx, db, prm, op, tableName, i); err != nil {
+				closeTasks(tasks)
 				return nil, err
 			}
 		}
 
-		var query string
-		if bulk {
-			query = getBulkInsertQuery(h.schemaName, tableName)
-		} else {
-			query = getInsertQuery(h.schemaName, tableName)
-		}
+		query := op.query(h.schemaName, tableName, h.table, bulk)
 
-		if tasks[i], err = newTask(db, query, i, batchSize); err != nil {
+		if err := tasks[prm.batchWorker(i)].addBatch(ctx, query, op.rows(h.table, prm.seed, i, prm.batchSize), tableName, i*prm.batchSize); err != nil {
+			closeTasks(tasks)
 			return nil, err
 		}
 	}
-	return tasks, err
+	return tasks, nil
 }
 
-func (h *TestHandler) bulkPar(db *sql.DB, batchCount, batchSize int, drop, separate bool, wait time.Duration) (time.Duration, error) {
-	var wg sync.WaitGroup
-
-	tasks, err := h.createTasks(db, batchCount, batchSize, true, drop, separate)
-	if err != nil {
-		return 0, err
+func closeTasks(tasks []*task) {
+	for _, t := range tasks {
+		t.close()
 	}
+}
+
+// runTasks executes f for each batch of the tasks (one goroutine per task) and returns the duration.
+func runTasks(m *monitor, tasks []*task, f func(worker int, t *task, b *taskBatch)) time.Duration {
+	var wg sync.WaitGroup
 
-	if wait > 0 {
-		time.Sleep(wait)
+	m.workers = len(tasks)
+	numBatch := make([]int, len(tasks))
+	for i, t := range tasks {
+		numBatch[i] = len(t.batches)
 	}
+	m.startWorkers(numBatch...)
 
 	t := time.Now() // Start time.
 
@@ -284,99 +975,152 @@ func (h *TestHandler) bulkPar(db *sql.DB, batchCount, batchSize int, drop, separ
 
 		go func(worker int, t *task) {
 			defer wg.Done()
+			defer m.startWorker()()
+			defer m.workerFinished(worker)
 
-			for _, row := range t.rows {
-				if _, err := t.stmt.Exec(row...); err != nil {
-					t.err = err
-				}
+			for _, b := range t.batches {
+				f(worker, t, b)
+				m.batchDone(worker)
 			}
-			// Call final stmt.Exec().
-			if _, err := t.stmt.Exec(); err != nil {
-				t.err = err
-			}
-
 		}(i, t)
 	}
 	wg.Wait()
 
-	d := time.Since(t) // Duration.
+	return time.Since(t) // Duration.
+}
 
-	for _, t := range tasks {
-		// return last error
-		err = t.err
-		t.close()
+// execBulk executes the batch b of worker via bulk statement executions.
+func execBulk(ctx context.Context, m *monitor, prm *testPrm, worker int, b *taskBatch) {
+	// Rows are buffered by the driver and flushed when reaching the bulk size (or by the final stmt.Exec()),
+	// so that rows are counted as successful after being flushed. As rows failing without being buffered
+	// (e.g. conversion errors) might occur between buffered rows, the index of each pending row is kept.
+	pending := make([]int, 0, prm.bulkSize) // indexes of the rows not flushed yet
+	rowOffset := func(i int) int {
+		if i < len(pending) {
+			return b.offset + pending[i]
+		}
+		return b.offset + len(b.rows) // no row pending
+	}
+	flush := func(d time.Duration, err error) {
+		failed := 0
+		if err != nil {
+			failed = m.failRows(worker, b.table, len(pending), rowOffset, err)
+		}
+		m.exec(d, len(pending)-failed)
+		pending = pending[:0]
 	}
 
-	return d, err
+	for j, row := range b.rows {
+		if err := ctx.Err(); err != nil {
+			// pending rows and rows not executed yet
+			first := b.offset + j
+			if len(pending) != 0 {
+				first = rowOffset(0)
+			}
+			m.fail(worker, b.table, first, len(pending)+len(b.rows)-j, err)
+			return
+		}
+		start := time.Now()
+		_, err := b.stmt.ExecContext(ctx, row...)
+		d := time.Since(start)
+		var dbErr driver.Error
+		switch {
+		case err != nil && !errors.As(err, &dbErr) && len(pending)+1 == prm.bulkSize:
+			// flushing row (e.g. connection lost) - buffered rows are lost as well
+			pending = append(pending, j)
+			m.failRows(worker, b.table, len(pending), rowOffset, err)
+			pending = pending[:0]
+		case err != nil && !errors.As(err, &dbErr): // row not buffered
+			m.fail(worker, b.table, b.offset+j, 1, err)
+		case err != nil || len(pending)+1 == prm.bulkSize: // rows flushed
+			pending = append(pending, j)
+			flush(d, err)
+		default: // row buffered - no round-trip
+			pending = append(pending, j)
+		}
+	}
+	// Call final stmt.Exec().
+	start := time.Now()
+	_, err := b.stmt.ExecContext(ctx)
+	if len(pending) != 0 || err != nil { // nothing flushed otherwise
+		flush(time.Since(start), err)
+	}
 }
 
-func (h *TestHandler) manyPar(db *sql.DB, batchCount, batchSize int, drop, separate bool, wait time.Duration) (time.Duration, error) {
-	var wg sync.WaitGroup
+// execMany executes the batch b of worker via 'many' statement executions of at most bulk size rows (packs).
+// The driver splits a 'many' execution into packs as well, but stops at the first failed pack without reporting
+// its start row. Executing the packs explicitly allows to report the failed rows by their offset in the batch.
+// All rows of a failed pack and of the subsequent packs (not executed) are considered as failed.
+func execMany(ctx context.Context, m *monitor, prm *testPrm, worker int, b *taskBatch) {
+	packSize := prm.bulkSize
+	if packSize <= 0 {
+		packSize = len(b.rows)
+	}
+	for start := 0; start < len(b.rows); start += packSize {
+		end := start + packSize
+		if end > len(b.rows) {
+			end = len(b.rows)
+		}
+		t := time.Now()
+		_, err := b.stmt.ExecContext(ctx, b.rows[start:end])
+		d := time.Since(t)
+		if err != nil {
+			m.failPack(worker, b.table, b.offset+start, end-start, len(b.rows)-end, err)
+			m.exec(d, 0)
+			return
+		}
+		m.exec(d, end-start)
+	}
+}
 
-	tasks, err := h.createTasks(db, batchCount, batchSize, false, drop, separate)
+func (h *TestHandler) bulkPar(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm, op dmlOp) (time.Duration, error) {
+	tasks, err := h.createTasks(ctx, db, prm, op, true)
 	if err != nil {
 		return 0, err
 	}
+	defer closeTasks(tasks)
+	m.rowSize = op.rowSize(h.table)
 
-	if wait > 0 {
-		time.Sleep(wait)
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
 	}
 
-	t := time.Now() // Start time.
-
-	for i, t := range tasks { // Start one worker per task.
-		wg.Add(1)
-
-		go func(worker int, t *task) {
-			defer wg.Done()
+	d := runTasks(m, tasks, func(worker int, t *task, b *taskBatch) { execBulk(ctx, m, prm, worker, b) })
 
-			if _, err := t.stmt.Exec(t.rows); err != nil {
-				t.err = err
-			}
+	return d, m.err()
+}
 
-		}(i, t)
+func (h *TestHandler) manyPar(ctx context.Context, m *monitor, db *sql.DB, prm *testPrm, op dmlOp) (time.Duration, error) {
+	tasks, err := h.createTasks(ctx, db, prm, op, false)
+	if err != nil {
+		return 0, err
 	}
-	wg.Wait()
-
-	d := time.Since(t) // Duration.
+	defer closeTasks(tasks)
+	m.rowSize = op.rowSize(h.table)
 
-	for _, t := range tasks {
-		// return last error
-		err = t.err
-		t.close()
+	if err := sleep(ctx, prm.wait); err != nil {
+		return 0, err
 	}
 
-	return d, err
+	d := runTasks(m, tasks, func(worker int, t *task, b *taskBatch) { execMany(ctx, m, prm, worker, b) })
+
+	return d, m.err()
 }
 
-func (h *TestHandler) setup(batchSize int) (*sql.DB, int, error) {
-	// Set bulk size to batchSize.
-	connector, err := driver.NewConnector(map[string]interface{}{"dsn": h.dsn, "bulkSize": batchSize, "bufferSize": env.BufferSize()})
+func (h *TestHandler) setup(prm *testPrm) (*sql.DB, *driver.Connector, error) {
+	// Set bulk size to batchSize if not defined.
+	bulkSize := prm.bulkSize
+	if bulkSize == 0 {
+		bulkSize = prm.batchSize
+	}
+	connector, err := h.connConfig.connector(bulkSize, prm.bufferSize, prm.fetchSize)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, err
 	}
-	return sql.OpenDB(connector), connector.BulkSize(), nil
+	return sql.OpenDB(connector), connector, nil
 }
 
 func (h *TestHandler) teardown(db *sql.DB) {
+	h.metrics.removeDB(db)
 	db.Close()
 }
-
-// randFloat64 return a random float64 number f with min <= f < max.
-func randFloat64(min, max float64) float64 {
-	return rand.Float64()*(max-min) + min
-}
-
-// randRow returns a table row with random float64 fields.
-func randRow(idx int) []interface{} {
-	return []interface{}{idx, randFloat64(25, 26), randFloat64(40, 60), randFloat64(500, 600), randFloat64(0.9, 1.1), randFloat64(23, 25), randFloat64(50, 60), randFloat64(0, 1), randFloat64(600, 800), randFloat64(400, 500)}
-}
-
-// randRows returns size table rows with random float64 fields.
-func randRows(i, size int) [][]interface{} {
-	rows := make([][]interface{}, size)
-	for j := 0; j < size; j++ {
-		rows[j] = randRow(i*size + j)
-	}
-	return rows
-}
@@ -0,0 +1,218 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestTestPrm(t *testing.T) {
+	newQuery := func(s string) *urlQuery {
+		values, err := url.ParseQuery(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &urlQuery{values: values}
+	}
+
+	prm, err := newTestPrm(newQuery("drop=false&separate=true&wait=5&buffersize=65536"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prm.drop || !prm.separate || prm.wait != 5*time.Second || prm.bufferSize != 65536 {
+		t.Fatalf("drop %t separate %t wait %s bufferSize %d - expected false true 5s 65536", prm.drop, prm.separate, prm.wait, prm.bufferSize)
+	}
+
+	for _, s := range []string{"drop=maybe", "separate=2", "wait=x", "wait=-1", "buffersize=1k", "buffersize=0", "bulksize=-1", "lobsize=-1", "lobsize=1M"} {
+		if _, err := newTestPrm(newQuery(s)); err == nil {
+			t.Fatalf("%s: error expected", s)
+		}
+	}
+}
+
+func TestValidDuration(t *testing.T) {
+	for _, test := range []struct {
+		query   string
+		timeout time.Duration
+		err     bool
+	}{
+		{"", 0, false},
+		{"timeout=30s", 30 * time.Second, false},
+		{"timeout=abc", 0, true},
+		{"timeout=-1s", 0, true},
+	} {
+		values, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		timeout, err := (&urlQuery{values: values}).getValidDuration(urlQueryTimeout, 0)
+		if (err != nil) != test.err || timeout != test.timeout {
+			t.Fatalf("%s: timeout %s error %v - expected %s", test.query, timeout, err, test.timeout)
+		}
+	}
+}
+
+func TestNumWorker(t *testing.T) {
+	for _, test := range []struct {
+		batchCount, workers int
+		numWorker           int
+		batchWorkers        []int
+	}{
+		{4, 0, 4, []int{0, 1, 2, 3}},       // one worker per batch
+		{4, -1, 4, []int{0, 1, 2, 3}},      // one worker per batch
+		{4, 8, 4, []int{0, 1, 2, 3}},       // clamped to batchCount
+		{5, 2, 2, []int{0, 1, 0, 1, 0}},    // round-robin
+		{6, 3, 3, []int{0, 1, 2, 0, 1, 2}}, // round-robin
+		{3, 1, 1, []int{0, 0, 0}},
+	} {
+		prm := &testPrm{batchCount: test.batchCount, workers: test.workers}
+		if n := prm.numWorker(); n != test.numWorker {
+			t.Fatalf("batchCount %d workers %d: number of workers %d - expected %d", test.batchCount, test.workers, n, test.numWorker)
+		}
+		for i, worker := range test.batchWorkers {
+			if w := prm.batchWorker(i); w != worker {
+				t.Fatalf("batchCount %d workers %d: worker of batch %d %d - expected %d", test.batchCount, test.workers, i, w, worker)
+			}
+		}
+	}
+}
+
+func TestIsSeqTest(t *testing.T) {
+	for _, test := range []string{TestBulkSeq, TestLobSeq, TestSelectSeq, TestUpsertManySeq, TestDeleteBulkSeq} {
+		if !IsSeqTest(test) {
+			t.Fatalf("%s: sequential test expected", test)
+		}
+	}
+	for _, test := range []string{TestBulkPar, TestLobPar, TestSelectPar, TestUpdateManyPar, TestSoakBulk} {
+		if IsSeqTest(test) {
+			t.Fatalf("%s: parallel test expected", test)
+		}
+	}
+}
+
+// bulkConnector is a database/sql connector simulating the bulk execution of the hdb driver:
+// rows are buffered until the bulk size is reached or the statement is executed without arguments.
+// Rows with value "invalid" fail without being buffered (conversion error) and flushes fail with the
+// errors of flushErrs in order. 'many' executions (rows as single argument) fail with the errors of
+// manyErrs in order (nil: successful execution).
+type bulkConnector struct {
+	bulkSize  int
+	flushErrs []error
+	manyErrs  []error
+}
+
+func (c *bulkConnector) Connect(context.Context) (driver.Conn, error) { return &bulkConn{c: c}, nil }
+func (c *bulkConnector) Driver() driver.Driver                        { return nil }
+
+type bulkConn struct{ c *bulkConnector }
+
+func (c *bulkConn) Prepare(query string) (driver.Stmt, error) { return &bulkStmt{c: c.c}, nil }
+func (c *bulkConn) Close() error                              { return nil }
+func (c *bulkConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type bulkStmt struct {
+	c          *bulkConnector
+	numPending int
+}
+
+func (s *bulkStmt) Close() error  { return nil }
+func (s *bulkStmt) NumInput() int { return -1 }
+func (s *bulkStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface accepting rows as 'many' argument.
+func (s *bulkStmt) CheckNamedValue(nv *driver.NamedValue) error { return nil }
+
+func (s *bulkStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if len(args) == 1 {
+		if _, ok := args[0].([][]interface{}); ok {
+			var err error
+			if len(s.c.manyErrs) != 0 {
+				err, s.c.manyErrs = s.c.manyErrs[0], s.c.manyErrs[1:]
+			}
+			return driver.ResultNoRows, err
+		}
+	}
+	if len(args) != 0 {
+		if args[0] == "invalid" {
+			return nil, errors.New("conversion error")
+		}
+		s.numPending++
+		if s.numPending < s.c.bulkSize {
+			return driver.ResultNoRows, nil
+		}
+	}
+	if s.numPending == 0 {
+		return driver.ResultNoRows, nil
+	}
+	s.numPending = 0
+	var err error
+	if len(s.c.flushErrs) != 0 {
+		err, s.c.flushErrs = s.c.flushErrs[0], s.c.flushErrs[1:]
+	}
+	return driver.ResultNoRows, err
+}
+
+func TestExecBulk(t *testing.T) {
+	ctx := context.Background()
+
+	// flush of rows 0, 2 and 3 fails for the first flushed row (row 0)
+	db := sql.OpenDB(&bulkConnector{bulkSize: 3, flushErrs: []error{&testDBError{stmtNo: []int{0}}}})
+	defer db.Close()
+	stmt, err := db.PrepareContext(ctx, "insert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	rows := [][]interface{}{{"0"}, {"invalid"}, {"2"}, {"3"}, {"4"}, {"5"}}
+
+	m := newMonitor()
+	execBulk(ctx, m, &testPrm{bulkSize: 3}, 1, &taskBatch{stmt: stmt, rows: rows, table: "T", offset: 100})
+
+	if m.rows() != 4 || m.failedRows() != 2 {
+		t.Fatalf("rows %d failed rows %d - expected %d %d", m.rows(), m.failedRows(), 4, 2)
+	}
+	errs := m.workerErrors()
+	if len(errs) != 2 || errs[0].RowOffset != 101 || errs[1].RowOffset != 100 {
+		t.Fatalf("invalid worker errors %v", errs)
+	}
+}
+
+func TestExecMany(t *testing.T) {
+	ctx := context.Background()
+
+	// packs of rows 0-3, 4-7 and 8-9: second pack fails for its second row (row 5)
+	db := sql.OpenDB(&bulkConnector{manyErrs: []error{nil, &testDBError{stmtNo: []int{1}}}})
+	defer db.Close()
+	stmt, err := db.PrepareContext(ctx, "insert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stmt.Close()
+
+	rows := make([][]interface{}, 10)
+	for i := range rows {
+		rows[i] = []interface{}{int64(i)}
+	}
+
+	m := newMonitor()
+	execMany(ctx, m, &testPrm{bulkSize: 4}, 1, &taskBatch{stmt: stmt, rows: rows, table: "T", offset: 100})
+
+	if m.rows() != 4 || m.failedRows() != 6 {
+		t.Fatalf("rows %d failed rows %d - expected %d %d", m.rows(), m.failedRows(), 4, 6)
+	}
+	errs := m.workerErrors()
+	if len(errs) != 1 || errs[0].RowOffset != 105 {
+		t.Fatalf("invalid worker errors %v", errs)
+	}
+}
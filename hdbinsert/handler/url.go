@@ -9,11 +9,47 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	urlQueryBatchCount = "batchcount"
 	urlQueryBatchSize  = "batchsize"
+	urlQueryAsync      = "async"
+	urlQueryTimeout    = "timeout"
+	urlQuerySeed       = "seed"
+	urlQueryLobSize    = "lobsize"
+	urlQueryFetchSize  = "fetchsize"
+	urlQueryColumns    = "columns"
+	urlQueryWorkers    = "workers"
+	urlQueryDuration   = "duration"
+	urlQueryRate       = "rate"
+	urlQueryWindow     = "window"
+	urlQueryRepeat     = "repeat"
+	urlQueryWarmup     = "warmup"
+	urlQueryFormat     = "format"
+	urlQueryStream     = "stream"
+	urlQueryInterval   = "interval"
+	urlQueryBufferSize = "buffersize"
+	urlQueryDrop       = "drop"
+	urlQuerySeparate   = "separate"
+	urlQueryWait       = "wait"
+	urlQueryBulkSize   = "bulksize"
+
+	urlQueryBufferSizes = "buffersizes"
+	urlQueryBulkSizes   = "bulksizes"
+
+	urlQueryTest          = "test"
+	urlQueryDriverVersion = "driverversion"
+	urlQueryHDBVersion    = "hdbversion"
+	urlQuerySince         = "since"
+	urlQueryUntil         = "until"
+	urlQueryLimit         = "limit"
+	urlQueryLabel         = "label"
+	urlQueryBase          = "base"
+	urlQueryCandidate     = "candidate"
+	urlQueryThreshold     = "threshold"
 
 	urlQuerySchemaName = "schemaname"
 	urlQueryTableName  = "tablename"
@@ -35,6 +71,26 @@ func (q *urlQuery) get(name string) (string, error) {
 	return v, nil
 }
 
+func (q *urlQuery) getString(name string, defValue string) string {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue
+	}
+	return s
+}
+
+func (q *urlQuery) getFloat64(name string, defValue float64) float64 {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return defValue
+	}
+	return f
+}
+
 func (q *urlQuery) getInt(name string, defValue int) int {
 	s, err := q.get(name)
 	if err != nil {
@@ -46,3 +102,126 @@ func (q *urlQuery) getInt(name string, defValue int) int {
 	}
 	return i
 }
+
+func (q *urlQuery) getInt64(name string, defValue int64) int64 {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return defValue
+	}
+	return i
+}
+
+func (q *urlQuery) getBool(name string, defValue bool) bool {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return defValue
+	}
+	return b
+}
+
+func (q *urlQuery) getDuration(name string, defValue time.Duration) time.Duration {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return defValue
+	}
+	return d
+}
+
+// getValidBool returns the boolean value of the query parameter name or an error if the value is not a valid boolean.
+func (q *urlQuery) getValidBool(name string, defValue bool) (bool, error) {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return defValue, fmt.Errorf("invalid url query value %s: %s", name, s)
+	}
+	return b, nil
+}
+
+// getValidDuration returns the duration value of the query parameter name or an error if the value is not a valid
+// non-negative duration.
+func (q *urlQuery) getValidDuration(name string, defValue time.Duration) (time.Duration, error) {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return defValue, fmt.Errorf("invalid url query value %s: %s", name, s)
+	}
+	return d, nil
+}
+
+// getValidInt returns the integer value of the query parameter name or an error if the value is not a valid integer.
+func (q *urlQuery) getValidInt(name string, defValue int) (int, error) {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue, nil
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return defValue, fmt.Errorf("invalid url query value %s: %s", name, s)
+	}
+	return i, nil
+}
+
+// getValidInts returns the comma separated integer values of the query parameter name or an error if a value
+// is not a valid integer. The default values are returned if the query parameter is not set.
+func (q *urlQuery) getValidInts(name string, defValues []int) ([]int, error) {
+	values := q.getStrings(name)
+	if len(values) == 0 {
+		return defValues, nil
+	}
+	ints := make([]int, len(values))
+	for i, v := range values {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url query value %s: %s", name, v)
+		}
+		ints[i] = n
+	}
+	return ints, nil
+}
+
+// getStrings returns the comma separated values of the query parameter name.
+func (q *urlQuery) getStrings(name string) []string {
+	s, err := q.get(name)
+	if err != nil {
+		return nil
+	}
+	values := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getTime returns the time value of the query parameter name in RFC3339 or date (2006-01-02) format.
+func (q *urlQuery) getTime(name string, defValue time.Time) time.Time {
+	s, err := q.get(name)
+	if err != nil {
+		return defValue
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return defValue
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/SAP/go-hdb/driver"
+)
+
+// maxWorkerErrors is the maximum number of worker errors kept per test.
+const maxWorkerErrors = 100
+
+// WorkerError is the structure used to provide an error of a parallel test worker.
+type WorkerError struct {
+	Worker    int
+	Table     string
+	RowOffset int // index of the (first) failed row in the test rows
+	Code      int // HANA error code (0 for non database errors)
+	Text      string
+}
+
+func (e *WorkerError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("worker %d table %s row %d: SQL Error %d - %s", e.Worker, e.Table, e.RowOffset, e.Code, e.Text)
+	}
+	return fmt.Sprintf("worker %d table %s row %d: %s", e.Worker, e.Table, e.RowOffset, e.Text)
+}
+
+// workerErrors is the error returned by parallel tests in case of worker errors.
+type workerErrors struct {
+	numError int
+	first    *WorkerError
+}
+
+func (e *workerErrors) Error() string {
+	if e.numError == 1 {
+		return e.first.Error()
+	}
+	return fmt.Sprintf("%d worker errors - first error: %s", e.numError, e.first)
+}
+
+// fail records the error err of worker executing numRow rows starting at rowOffset in table and returns
+// the number of failed rows. In case of database errors of multi row executions (bulk, many) only the rows
+// reported by the database are considered as failed.
+func (m *monitor) fail(worker int, table string, rowOffset, numRow int, err error) int {
+	return m.failRows(worker, table, numRow, func(i int) int { return rowOffset + i }, err)
+}
+
+// failRows is like fail for numRow rows which are not necessarily contiguous. rowOffset returns the
+// offset of the i-th executed row.
+func (m *monitor) failRows(worker int, table string, numRow int, rowOffset func(i int) int, err error) int {
+	errs, failed := newWorkerErrors(worker, table, numRow, rowOffset, err)
+	m.record(errs, failed)
+	return failed
+}
+
+// failPack records the error err of worker executing a 'many' pack of numRow rows starting at rowOffset in table,
+// which aborted the execution of the numSkipped subsequent rows. All rows of the pack as well as the subsequent
+// rows are considered as failed.
+func (m *monitor) failPack(worker int, table string, rowOffset, numRow, numSkipped int, err error) {
+	errs, _ := newWorkerErrors(worker, table, numRow, func(i int) int { return rowOffset + i }, err)
+	m.record(errs, numRow+numSkipped)
+}
+
+// newWorkerErrors returns the worker errors of err and the number of failed rows (see fail).
+func newWorkerErrors(worker int, table string, numRow int, rowOffset func(i int) int, err error) ([]*WorkerError, int) {
+	var errs []*WorkerError
+	failed := numRow
+
+	var dbErr driver.Error
+	isDBErr := errors.As(err, &dbErr)
+
+	if isDBErr && numRow > 1 {
+		failed = 0
+		for i := 0; i < dbErr.NumError(); i++ {
+			dbErr.SetIdx(i)
+			if dbErr.IsWarning() {
+				continue
+			}
+			errs = append(errs, &WorkerError{Worker: worker, Table: table, RowOffset: rowOffset(dbErr.StmtNo()), Code: dbErr.Code(), Text: dbErr.Text()})
+			failed++
+		}
+	}
+	if len(errs) == 0 {
+		we := &WorkerError{Worker: worker, Table: table, RowOffset: rowOffset(0), Text: err.Error()}
+		if isDBErr {
+			we.Code, we.Text = dbErr.Code(), dbErr.Text()
+		}
+		errs = append(errs, we)
+		failed = numRow
+	}
+	return errs, failed
+}
+
+// failBatch records the error err of worker executing the batch of numRow rows starting at rowOffset in table
+// as a whole (failed query or rolled back transaction), so that all rows of the batch are considered as failed.
+func (m *monitor) failBatch(worker int, table string, rowOffset, numRow int, err error) {
+	we := &WorkerError{Worker: worker, Table: table, RowOffset: rowOffset, Text: err.Error()}
+	var dbErr driver.Error
+	if errors.As(err, &dbErr) {
+		we.Code, we.Text = dbErr.Code(), dbErr.Text()
+	}
+	m.record([]*WorkerError{we}, numRow)
+}
+
+// record adds the worker errors errs and numFailedRow failed rows.
+func (m *monitor) record(errs []*WorkerError, numFailedRow int) {
+	atomic.AddInt64(&m.numFailedRow, int64(numFailedRow))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.numError += len(errs)
+	for _, e := range errs {
+		if len(m.errs) >= maxWorkerErrors {
+			break
+		}
+		m.errs = append(m.errs, e)
+	}
+}
+
+// err returns an error summarizing the worker errors or nil if no worker error occurred.
+func (m *monitor) err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.numError == 0 {
+		return nil
+	}
+	return &workerErrors{numError: m.numError, first: m.errs[0]}
+}
+
+// workerErrors returns the recorded worker errors.
+func (m *monitor) workerErrors() []*WorkerError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errs
+}
+
+// failedRows returns the number of failed rows.
+func (m *monitor) failedRows() int64 { return atomic.LoadInt64(&m.numFailedRow) }
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testDBError implements the driver.Error interface.
+type testDBError struct {
+	idx    int
+	stmtNo []int
+}
+
+func (e *testDBError) Error() string   { return fmt.Sprintf("%d errors", len(e.stmtNo)) }
+func (e *testDBError) NumError() int   { return len(e.stmtNo) }
+func (e *testDBError) SetIdx(idx int)  { e.idx = idx }
+func (e *testDBError) StmtNo() int     { return e.stmtNo[e.idx] }
+func (e *testDBError) Code() int       { return 301 }
+func (e *testDBError) Position() int   { return 0 }
+func (e *testDBError) Level() int      { return 1 }
+func (e *testDBError) Text() string    { return "unique constraint violated" }
+func (e *testDBError) IsWarning() bool { return false }
+func (e *testDBError) IsError() bool   { return true }
+func (e *testDBError) IsFatal() bool   { return false }
+
+func TestWorkerError(t *testing.T) {
+	m := newMonitor()
+
+	if err := m.err(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	// database error of multi row execution
+	if failed := m.fail(1, "T_1", 1000, 100, &testDBError{stmtNo: []int{3, 7}}); failed != 2 {
+		t.Fatalf("failed rows %d - expected %d", failed, 2)
+	}
+	// non database error
+	if failed := m.fail(2, "T_2", 2000, 100, errors.New("connection lost")); failed != 100 {
+		t.Fatalf("failed rows %d - expected %d", failed, 100)
+	}
+	// database error of a batch executed as a whole (e.g. rolled back transaction)
+	m.failBatch(3, "T_3", 3000, 100, &testDBError{stmtNo: []int{5}})
+
+	if m.failedRows() != 202 {
+		t.Fatalf("failed rows %d - expected %d", m.failedRows(), 202)
+	}
+
+	errs := m.workerErrors()
+	if len(errs) != 4 {
+		t.Fatalf("number of errors %d - expected %d", len(errs), 4)
+	}
+	expected := []WorkerError{
+		{Worker: 1, Table: "T_1", RowOffset: 1003, Code: 301, Text: "unique constraint violated"},
+		{Worker: 1, Table: "T_1", RowOffset: 1007, Code: 301, Text: "unique constraint violated"},
+		{Worker: 2, Table: "T_2", RowOffset: 2000, Text: "connection lost"},
+		{Worker: 3, Table: "T_3", RowOffset: 3000, Code: 301, Text: "unique constraint violated"},
+	}
+	for i, e := range errs {
+		if *e != expected[i] {
+			t.Fatalf("error %v - expected %v", *e, expected[i])
+		}
+	}
+
+	if err := m.err(); err == nil || err.Error() != "4 worker errors - first error: "+errs[0].Error() {
+		t.Fatalf("invalid error %v", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"os/signal"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
 	"github.com/stfnmllr/go-hdb-test/hdbinsert/handler"
@@ -23,16 +24,41 @@ import (
 	_ "net/http/pprof"
 )
 
-func main() {
-	if !flag.Parsed() {
-		flag.Parse()
+func checkErr(err error) {
+	if err != nil {
+		log.Fatal(err)
 	}
+}
 
-	checkErr := func(err error) {
-		if err != nil {
-			log.Fatal(err)
+// command splits the command-line arguments into an optional command and the remaining arguments.
+func command(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "", args
+	}
+	return args[0], args[1:]
+}
+
+func main() {
+	// Command-line flags might be provided before and after the command and its arguments.
+	cmd, args := command(os.Args[1:])
+	flag.CommandLine.Parse(args) // exits on error
+	if cmd == "" && flag.NArg() != 0 {
+		cmd, args = command(flag.Args())
+		flag.CommandLine.Parse(args)
+	}
+	cmdArgs := []string{}
+	for flag.NArg() != 0 {
+		cmdArgs = append(cmdArgs, flag.Arg(0))
+		flag.CommandLine.Parse(flag.Args()[1:])
+	}
+	// Only the plan command takes arguments (test plan files).
+	switch cmd {
+	case "", cmdRun, cmdCompare, cmdSweep:
+		if len(cmdArgs) != 0 {
+			log.Fatalf("unexpected arguments %s", strings.Join(cmdArgs, " "))
 		}
 	}
+	checkErr(env.LoadConfig())
 
 	// Print runtime info.
 	log.Printf("Runtime Info - GOMAXPROCS: %d NumCPU: %d", runtime.GOMAXPROCS(0), runtime.NumCPU())
@@ -43,11 +69,39 @@ func main() {
 	})
 	log.Printf("Command line flags: %s", strings.Join(s, " "))
 
+	switch cmd {
+	case "":
+		serve()
+	case cmdRun:
+		os.Exit(run())
+	case cmdCompare:
+		os.Exit(compare())
+	case cmdSweep:
+		os.Exit(sweep())
+	case cmdPlan:
+		os.Exit(plan(cmdArgs))
+	default:
+		log.Fatalf("unknown command %s", cmd)
+	}
+}
+
+// serve starts the HTTP server.
+func serve() {
 	// Create handlers.
 	dbHandler, err := handler.NewDBHandler(log.Printf)
 	checkErr(err)
 	testHandler, err := handler.NewTestHandler(log.Printf)
 	checkErr(err)
+	jobHandler, err := handler.NewJobHandler(log.Printf, testHandler)
+	checkErr(err)
+	metricsHandler, err := handler.NewMetricsHandler(testHandler)
+	checkErr(err)
+	resultHandler, err := handler.NewResultHandler(log.Printf, testHandler)
+	checkErr(err)
+	compareHandler, err := handler.NewCompareHandler(log.Printf, testHandler)
+	checkErr(err)
+	sweepHandler, err := handler.NewSweepHandler(log.Printf, testHandler)
+	checkErr(err)
 	indexHandler, err := handler.NewIndexHandler(testHandler, dbHandler)
 	checkErr(err)
 
@@ -57,6 +111,11 @@ func main() {
 	mux := http.NewServeMux()
 
 	mux.Handle("/test/", testHandler)
+	mux.Handle(handler.JobPath, jobHandler)
+	mux.Handle(handler.MetricsPath, metricsHandler)
+	mux.Handle(handler.ResultPath, resultHandler)
+	mux.Handle(handler.ComparePath, compareHandler)
+	mux.Handle(handler.SweepPath, sweepHandler)
 	mux.Handle("/db/", dbHandler)
 	mux.Handle("/", indexHandler)
 	mux.HandleFunc("/favicon.ico", func(http.ResponseWriter, *http.Request) {}) // Avoid "/" handler call for browser favicon request.
@@ -73,6 +132,14 @@ func main() {
 	<-sigint
 	// shutdown server
 	log.Println("shutting down...")
+
+	// Drain running tests - tests still running after the shutdown timeout are aborted.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(env.ShutdownTimeout())*time.Second)
+	defer cancel()
+	if err := testHandler.Shutdown(ctx); err != nil {
+		log.Printf("running tests aborted: %v", err)
+	}
+
 	if err := svr.Shutdown(context.Background()); err != nil {
 		log.Fatalf("HTTP server Shutdown: %v", err)
 	}
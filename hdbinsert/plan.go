@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"text/tabwriter"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/handler"
+)
+
+// cmdPlan executes the scenarios of test plan files without HTTP server.
+const cmdPlan = "plan"
+
+// plan executes the scenarios of the test plan files fns in order, prints a summary per plan
+// and returns the exit code (0: all scenarios were successful, 1: at least one scenario failed).
+func plan(fns []string) int {
+	if len(fns) == 0 {
+		log.Print("no test plan file")
+		return 1
+	}
+
+	plans := make([]*handler.Plan, len(fns))
+	for i, fn := range fns {
+		p, err := handler.ReadPlan(fn)
+		checkErr(err)
+		plans[i] = p
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dbHandler, err := handler.NewDBHandler(log.Printf)
+	checkErr(err)
+	testHandler, err := handler.NewTestHandler(log.Printf)
+	checkErr(err)
+
+	log.Printf("Driver Version: %s HANA Version: %s", dbHandler.DriverVersion(), dbHandler.HDBVersion())
+
+	format := env.Format()
+	if format != "" {
+		format, err = handler.ParseResultFormat(format)
+		checkErr(err)
+	}
+	results := []*handler.TestResult{}
+
+	numError := 0
+	for _, p := range plans {
+		if ctx.Err() != nil {
+			break
+		}
+		r := testHandler.RunPlan(ctx, p)
+		results = append(results, r.Results()...)
+		if r.Error != "" || r.NumError != 0 {
+			numError++
+		}
+		if format == "" {
+			printPlan(r)
+		}
+	}
+	if format != "" {
+		checkErr(handler.WriteResults(os.Stdout, format, results))
+	}
+
+	if err := ctx.Err(); err != nil {
+		log.Printf("test plan aborted: %v", err)
+		return 1
+	}
+	if numError != 0 {
+		log.Printf("%d test plan(s) failed", numError)
+		return 1
+	}
+	return 0
+}
+
+// printPlan prints the summary of a test plan with one line per scenario.
+func printPlan(r *handler.PlanResult) {
+	fmt.Printf("Plan %s\n", r.Name)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Scenario\tTest\tBatchCount\tBatchSize\tWorkers\tBufferSize\tBulkSize\tRows\tSeconds\tRows/s\tMB/s\tError")
+	for _, s := range r.Scenarios {
+		tr := s.Result
+		if tr == nil {
+			fmt.Fprintf(w, "%s\t\t\t\t\t\t\t\t\t\t\t%s\n", s.Name, s.Error)
+			continue
+		}
+		seconds := tr.Seconds
+		if tr.Stats != nil { // median of repeated runs
+			seconds = tr.Stats.Median
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.3f\t%.0f\t%.2f\t%s\n", s.Name, path.Base(tr.Test), tr.BatchCount, tr.BatchSize, tr.Workers, tr.BufferSize, tr.BulkSize, tr.NumRow, seconds, tr.RowsPerSecond, tr.MBPerSecond, s.Error)
+	}
+	w.Flush()
+	fmt.Println(r)
+	fmt.Println()
+}
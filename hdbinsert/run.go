@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/handler"
+)
+
+// cmdRun executes the tests without HTTP server.
+const cmdRun = "run"
+
+// selectTests returns the tests contained in the comma separated list names or all tests
+// except the (time based) soak tests if names is empty.
+func selectTests(all []string, names string) ([]string, error) {
+	if names == "" {
+		selected := []string{}
+		for _, test := range all {
+			if !handler.IsSoakTest(test) {
+				selected = append(selected, test)
+			}
+		}
+		return selected, nil
+	}
+	byName := make(map[string]string, len(all))
+	for _, test := range all {
+		byName[strings.ToLower(path.Base(test))] = test
+	}
+	selected := []string{}
+	for _, name := range strings.Split(names, ",") {
+		test, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown test %s", name)
+		}
+		selected = append(selected, test)
+	}
+	return selected, nil
+}
+
+// testSet is a set of tests executed with the same batchCount x batchSize parameters.
+type testSet struct {
+	prms  []env.Prm
+	tests []string
+}
+
+// testSets splits tests into the tests executed with the parameters command-line flag and the large object tests
+// executed with the lobParameters command-line flag.
+func testSets(tests []string) []testSet {
+	sets := []testSet{{prms: env.Parameters().Prms}, {prms: env.LobParameters().Prms}}
+	for _, test := range tests {
+		if handler.IsLobTest(test) {
+			sets[1].tests = append(sets[1].tests, test)
+		} else {
+			sets[0].tests = append(sets[0].tests, test)
+		}
+	}
+	return sets
+}
+
+// run executes the tests count times for all parameters and numbers of workers (see parameters, lobParameters
+// and workers command-line flag, sequential tests are executed for the first number of workers only), prints the test results
+// and returns the exit code (0: all tests were successful, 1: at least one test failed).
+func run() int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dbHandler, err := handler.NewDBHandler(log.Printf)
+	checkErr(err)
+	testHandler, err := handler.NewTestHandler(log.Printf)
+	checkErr(err)
+
+	log.Printf("Driver Version: %s HANA Version: %s", dbHandler.DriverVersion(), dbHandler.HDBVersion())
+
+	tests, err := selectTests(testHandler.Tests(), env.Tests())
+	checkErr(err)
+
+	format := env.Format()
+	if format != "" {
+		format, err = handler.ParseResultFormat(format)
+		checkErr(err)
+	}
+	results := []*handler.TestResult{}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Test\tBatchCount\tBatchSize\tWorkers\tRows\tSeconds\tRows/s\tMB/s\tp50\tp99\tError")
+
+	numError := 0
+	for _, set := range testSets(tests) {
+		for _, prm := range set.prms {
+			for j, workers := range env.Workers().Workers {
+				for _, test := range set.tests {
+					if j > 0 && handler.IsSeqTest(test) {
+						continue // sequential tests ignore the number of workers
+					}
+					for i := 0; i < env.Count(); i++ {
+						if ctx.Err() != nil {
+							break
+						}
+						r := testHandler.Run(ctx, test, prm.BatchCount, prm.BatchSize, workers)
+						results = append(results, r)
+						var p50, p99 string
+						if r.Latency != nil {
+							p50, p99 = r.Latency.P50.String(), r.Latency.P99.String()
+						}
+						fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.3f\t%.0f\t%.2f\t%s\t%s\t%s\n", path.Base(r.Test), r.BatchCount, r.BatchSize, r.Workers, r.NumRow, r.Seconds, r.RowsPerSecond, r.MBPerSecond, p50, p99, r.Error)
+						for _, window := range r.Windows {
+							log.Printf("%s window %s", path.Base(r.Test), window)
+						}
+						if r.Error != "" {
+							numError++
+						}
+					}
+				}
+			}
+		}
+	}
+	if format == "" {
+		w.Flush()
+	} else {
+		checkErr(handler.WriteResults(os.Stdout, format, results))
+	}
+
+	if err := ctx.Err(); err != nil {
+		log.Printf("test run aborted: %v", err)
+		return 1
+	}
+	if numError != 0 {
+		log.Printf("%d test(s) failed", numError)
+		return 1
+	}
+	return 0
+}
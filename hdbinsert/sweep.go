@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2020-2021 Stefan Miller
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"text/tabwriter"
+
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/env"
+	"github.com/stfnmllr/go-hdb-test/hdbinsert/handler"
+)
+
+// cmdSweep executes the tests for all combinations of buffer sizes and bulk sizes without HTTP server.
+const cmdSweep = "sweep"
+
+// sweep executes the tests (default BulkSeq) for all combinations of buffer sizes and bulk sizes (see sweepBufferSize
+// and sweepBulkSize command-line flag) for all parameters and numbers of workers (sequential tests for the first
+// number of workers only), prints the rows per second
+// per combination and the optimum and returns the exit code (0: all tests were successful, 1: at least one test failed).
+func sweep() int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dbHandler, err := handler.NewDBHandler(log.Printf)
+	checkErr(err)
+	testHandler, err := handler.NewTestHandler(log.Printf)
+	checkErr(err)
+
+	log.Printf("Driver Version: %s HANA Version: %s", dbHandler.DriverVersion(), dbHandler.HDBVersion())
+
+	names := env.Tests()
+	if names == "" {
+		names = path.Base(handler.TestBulkSeq)
+	}
+	tests, err := selectTests(testHandler.Tests(), names)
+	checkErr(err)
+
+	format := env.Format()
+	if format != "" {
+		format, err = handler.ParseResultFormat(format)
+		checkErr(err)
+	}
+	sweeps := []*handler.SweepResult{}
+
+	bufferSizes, bulkSizes := env.SweepBufferSize().Sizes, env.SweepBulkSize().Sizes
+
+	numError := 0
+	for _, set := range testSets(tests) {
+		for _, prm := range set.prms {
+			for j, workers := range env.Workers().Workers {
+				for _, test := range set.tests {
+					if j > 0 && handler.IsSeqTest(test) {
+						continue // sequential tests ignore the number of workers
+					}
+					if ctx.Err() != nil {
+						break
+					}
+					s := testHandler.Sweep(ctx, test, prm.BatchCount, prm.BatchSize, workers, bufferSizes, bulkSizes)
+					sweeps = append(sweeps, s)
+					if s.Error != "" || s.NumError != 0 {
+						numError++
+					}
+					if format == "" {
+						printSweep(s)
+					}
+				}
+			}
+		}
+	}
+	if format != "" {
+		checkErr(handler.WriteSweeps(os.Stdout, format, sweeps))
+	}
+
+	if err := ctx.Err(); err != nil {
+		log.Printf("sweep aborted: %v", err)
+		return 1
+	}
+	if numError != 0 {
+		log.Printf("%d sweep(s) failed", numError)
+		return 1
+	}
+	return 0
+}
+
+// printSweep prints the rows per second of a sweep as table with one line per buffer size
+// and one column per bulk size followed by the optimum.
+func printSweep(s *handler.SweepResult) {
+	fmt.Printf("%s BatchCount %d BatchSize %d Workers %d\n", path.Base(s.Test), s.BatchCount, s.BatchSize, s.Workers)
+	if s.RowsPerSecond != nil {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprint(w, "BufferSize\\BulkSize\t")
+		for _, bulkSize := range s.BulkSizes {
+			fmt.Fprintf(w, "%d\t", bulkSize)
+		}
+		fmt.Fprintln(w)
+		for i, bufferSize := range s.BufferSizes {
+			fmt.Fprintf(w, "%d\t", bufferSize)
+			for _, rowsPerSecond := range s.RowsPerSecond[i] {
+				fmt.Fprintf(w, "%.0f\t", rowsPerSecond)
+			}
+			fmt.Fprintln(w)
+		}
+		w.Flush()
+	}
+	fmt.Println(s)
+	fmt.Println()
+}